@@ -0,0 +1,97 @@
+// Package apperror defines the typed errors returned by repositories and services.
+// Handlers map them to HTTP statuses and problem+json bodies in one place.
+package apperror
+
+import (
+	"errors"
+	"fmt"
+)
+
+type Code string
+
+const (
+	CodeBadRequest        Code = "bad_request"
+	CodeMethodNotAllowed  Code = "method_not_allowed"
+	CodePayloadTooLarge   Code = "payload_too_large"
+	CodeValidation        Code = "validation"
+	CodeNotFound          Code = "not_found"
+	CodeConflict          Code = "conflict"
+	CodeInsufficientStock Code = "insufficient_stock"
+	CodeRateLimited       Code = "rate_limited"
+	CodeInternal          Code = "internal"
+)
+
+// FieldError describes a problem with a single request field.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+type Error struct {
+	Code    Code
+	Message string
+	Fields  []FieldError
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func New(code Code, format string, args ...interface{}) *Error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+func BadRequest(format string, args ...interface{}) *Error {
+	return New(CodeBadRequest, format, args...)
+}
+
+func NotFound(format string, args ...interface{}) *Error {
+	return New(CodeNotFound, format, args...)
+}
+
+func Conflict(format string, args ...interface{}) *Error {
+	return New(CodeConflict, format, args...)
+}
+
+func InsufficientStock(format string, args ...interface{}) *Error {
+	return New(CodeInsufficientStock, format, args...)
+}
+
+// Validation reports one invalid field; use Invalid for several at once.
+func Validation(field, message string) *Error {
+	return Invalid(FieldError{Field: field, Message: message})
+}
+
+func Invalid(fields ...FieldError) *Error {
+	message := "request validation failed"
+	if len(fields) == 1 {
+		message = fields[0].Message
+	}
+	return &Error{Code: CodeValidation, Message: message, Fields: fields}
+}
+
+// Wrap keeps err for logging while exposing only message to the client.
+func Wrap(code Code, err error, message string) *Error {
+	return &Error{Code: code, Message: message, Err: err}
+}
+
+// CodeOf returns the code of the first *Error in err's chain, or CodeInternal.
+func CodeOf(err error) Code {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr.Code
+	}
+	return CodeInternal
+}
+
+func Is(err error, code Code) bool {
+	return CodeOf(err) == code
+}
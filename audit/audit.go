@@ -0,0 +1,45 @@
+// Package audit carries the acting user through the request context and
+// computes the field changes stored with each audit log entry.
+package audit
+
+import (
+	"context"
+	"reflect"
+)
+
+type actorKey struct{}
+
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// Actor returns the user acting on the request, or "" when unknown.
+func Actor(ctx context.Context) string {
+	actor, _ := ctx.Value(actorKey{}).(string)
+	return actor
+}
+
+// Record is the audited state of an entity, keyed by JSON field name.
+type Record map[string]any
+
+type Change struct {
+	From any `json:"from"`
+	To   any `json:"to"`
+}
+
+// Diff lists the fields whose value differs between before and after. A field
+// missing on one side is reported with a nil value there.
+func Diff(before, after Record) map[string]Change {
+	changes := map[string]Change{}
+	for key, from := range before {
+		if to := after[key]; !reflect.DeepEqual(from, to) {
+			changes[key] = Change{From: from, To: to}
+		}
+	}
+	for key, to := range after {
+		if _, ok := before[key]; !ok {
+			changes[key] = Change{To: to}
+		}
+	}
+	return changes
+}
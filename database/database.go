@@ -2,16 +2,19 @@ package database
 
 import (
 	"database/sql"
-	"log"
+	"log/slog"
 
-	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
 )
 
 func InitDB(connectionString string) (*sql.DB, error) {
-	db, err := sql.Open("pgx", connectionString)
+	config, err := pgx.ParseConfig(connectionString)
 	if err != nil {
 		return nil, err
 	}
+	config.Tracer = queryTracer{}
+	db := stdlib.OpenDB(*config)
 
 	// test connection
 	err = db.Ping()
@@ -23,6 +26,6 @@ func InitDB(connectionString string) (*sql.DB, error) {
 	db.SetMaxOpenConns(25)
 	db.SetMaxIdleConns(5)
 
-	log.Println("Database connection established")
+	slog.Info("database connection established")
 	return db, nil
 }
@@ -0,0 +1,83 @@
+// Package dbtest gives integration tests a migrated, empty PostgreSQL
+// database, taken from TEST_DATABASE_URL.
+package dbtest
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"strings"
+	"testing"
+
+	"kasir-api/database"
+)
+
+// lockKey serializes tests sharing the database; go test runs packages in parallel.
+const lockKey = 4242
+
+// Open connects to TEST_DATABASE_URL, migrates it and empties every table.
+// The test is skipped when the variable is unset. The database is held by
+// the test until it finishes.
+func Open(t testing.TB) *sql.DB {
+	t.Helper()
+
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	db, err := database.InitDB(url)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockKey); err != nil {
+		t.Fatalf("lock database: %v", err)
+	}
+	t.Cleanup(func() {
+		conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", lockKey)
+		conn.Close()
+	})
+
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if err := truncate(ctx, db); err != nil {
+		t.Fatalf("truncate: %v", err)
+	}
+	return db
+}
+
+func truncate(ctx context.Context, db *sql.DB) error {
+	rows, err := db.QueryContext(ctx, `
+		SELECT quote_ident(tablename) FROM pg_tables
+		WHERE schemaname = current_schema() AND tablename <> 'schema_migrations'`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	tables := make([]string, 0)
+	for rows.Next() {
+		var table string
+		if err := rows.Scan(&table); err != nil {
+			return err
+		}
+		tables = append(tables, table)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(tables) == 0 {
+		return nil
+	}
+
+	_, err = db.ExecContext(ctx, "TRUNCATE "+strings.Join(tables, ", ")+" RESTART IDENTITY CASCADE")
+	return err
+}
@@ -0,0 +1,11 @@
+ALTER TABLE transactions
+    ADD COLUMN IF NOT EXISTS cashier_id VARCHAR(64) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS terminal_id VARCHAR(64) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS store_id VARCHAR(64) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS status VARCHAR(16) NOT NULL DEFAULT 'completed',
+    ADD COLUMN IF NOT EXISTS status_changed_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS status_changed_by VARCHAR(64) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS status_reason TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_transactions_cashier_created ON transactions (cashier_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_transactions_terminal_created ON transactions (terminal_id, created_at);
@@ -0,0 +1,39 @@
+CREATE TABLE IF NOT EXISTS shifts (
+    id SERIAL PRIMARY KEY,
+    cashier_id VARCHAR(64) NOT NULL,
+    terminal_id VARCHAR(64) NOT NULL,
+    store_id VARCHAR(64) NOT NULL DEFAULT '',
+    opening_float INT NOT NULL DEFAULT 0,
+    status VARCHAR(16) NOT NULL DEFAULT 'open',
+    opened_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    closed_at TIMESTAMP,
+    closed_by VARCHAR(64) NOT NULL DEFAULT ''
+);
+
+-- one open shift per register
+CREATE UNIQUE INDEX IF NOT EXISTS idx_shifts_open_terminal ON shifts (terminal_id) WHERE status = 'open';
+
+CREATE TABLE IF NOT EXISTS shift_cash_movements (
+    id SERIAL PRIMARY KEY,
+    shift_id INT NOT NULL REFERENCES shifts(id),
+    type VARCHAR(16) NOT NULL,
+    amount INT NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    cashier_id VARCHAR(64) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS shift_counts (
+    shift_id INT NOT NULL REFERENCES shifts(id),
+    payment_method VARCHAR(32) NOT NULL,
+    counted_amount INT NOT NULL,
+    PRIMARY KEY (shift_id, payment_method)
+);
+
+ALTER TABLE transactions
+    ADD COLUMN IF NOT EXISTS payment_method VARCHAR(32) NOT NULL DEFAULT 'cash',
+    ADD COLUMN IF NOT EXISTS shift_id INT REFERENCES shifts(id),
+    ADD COLUMN IF NOT EXISTS status_shift_id INT REFERENCES shifts(id);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_shift ON transactions (shift_id);
+CREATE INDEX IF NOT EXISTS idx_transactions_status_shift ON transactions (status_shift_id);
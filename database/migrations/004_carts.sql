@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS carts (
+    id SERIAL PRIMARY KEY,
+    status VARCHAR(16) NOT NULL DEFAULT 'active',
+    cashier_id VARCHAR(64) NOT NULL DEFAULT '',
+    terminal_id VARCHAR(64) NOT NULL DEFAULT '',
+    store_id VARCHAR(64) NOT NULL DEFAULT '',
+    note TEXT NOT NULL DEFAULT '',
+    reserve_stock BOOLEAN NOT NULL DEFAULT FALSE,
+    reserved_until TIMESTAMP,
+    transaction_id INT REFERENCES transactions(id),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_carts_status ON carts (status);
+
+CREATE TABLE IF NOT EXISTS cart_items (
+    cart_id INT NOT NULL REFERENCES carts(id) ON DELETE CASCADE,
+    product_id INT NOT NULL REFERENCES products(id),
+    quantity INT NOT NULL,
+    PRIMARY KEY (cart_id, product_id)
+);
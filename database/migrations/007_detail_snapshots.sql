@@ -0,0 +1,19 @@
+ALTER TABLE products
+    ADD COLUMN IF NOT EXISTS sku VARCHAR(64) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS cost INT NOT NULL DEFAULT 0;
+
+ALTER TABLE transaction_details
+    ADD COLUMN IF NOT EXISTS product_name VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS sku VARCHAR(64) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS unit_price INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS discount INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS cost INT NOT NULL DEFAULT 0;
+
+-- Lines sold before snapshots existed take the product as it is now.
+UPDATE transaction_details td
+SET product_name = p.name,
+    sku = p.sku,
+    unit_price = CASE WHEN td.quantity > 0 THEN td.subtotal / td.quantity ELSE 0 END,
+    cost = p.cost
+FROM products p
+WHERE td.product_id = p.id AND td.product_name = '';
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS product_prices (
+    id SERIAL PRIMARY KEY,
+    product_id INT NOT NULL REFERENCES products(id),
+    price INT NOT NULL,
+    effective_at TIMESTAMPTZ NOT NULL,
+    created_by VARCHAR(64) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_prices_product_effective ON product_prices (product_id, effective_at);
+
+-- Start every product's history with the price it has now.
+INSERT INTO product_prices (product_id, price, effective_at)
+SELECT p.id, p.price, NOW()
+FROM products p
+WHERE NOT EXISTS (SELECT 1 FROM product_prices pp WHERE pp.product_id = p.id);
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS price_rules (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    product_id INT REFERENCES products(id),
+    category_id INT REFERENCES categories(id),
+    type VARCHAR(16) NOT NULL,
+    value INT NOT NULL,
+    days_mask INT NOT NULL,          -- bit n set: applies on weekday n, Sunday = 0
+    start_time TIME NOT NULL,        -- store local time
+    end_time TIME NOT NULL,          -- exclusive; before start_time for windows past midnight
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMP,
+    CHECK ((product_id IS NULL) <> (category_id IS NULL))
+);
+
+CREATE INDEX IF NOT EXISTS idx_price_rules_product ON price_rules (product_id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_price_rules_category ON price_rules (category_id) WHERE deleted_at IS NULL;
+
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS price_rule_id INT REFERENCES price_rules(id);
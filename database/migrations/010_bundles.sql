@@ -0,0 +1,36 @@
+ALTER TABLE products ADD COLUMN IF NOT EXISTS is_bundle BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Fixed contents of a bundle, per bundle sold
+CREATE TABLE IF NOT EXISTS bundle_components (
+    id SERIAL PRIMARY KEY,
+    bundle_id INT NOT NULL REFERENCES products(id),
+    product_id INT NOT NULL REFERENCES products(id),
+    quantity INT NOT NULL CHECK (quantity > 0),
+    UNIQUE (bundle_id, product_id)
+);
+
+-- Free picks of a bundle: quantity items of any product in the category
+CREATE TABLE IF NOT EXISTS bundle_choice_groups (
+    id SERIAL PRIMARY KEY,
+    bundle_id INT NOT NULL REFERENCES products(id),
+    name VARCHAR(100) NOT NULL,
+    category_id INT NOT NULL REFERENCES categories(id),
+    quantity INT NOT NULL CHECK (quantity > 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_bundle_components_bundle ON bundle_components (bundle_id);
+CREATE INDEX IF NOT EXISTS idx_bundle_choice_groups_bundle ON bundle_choice_groups (bundle_id);
+
+-- What a sold bundle line was made of, with its share of the line revenue
+CREATE TABLE IF NOT EXISTS transaction_detail_components (
+    id SERIAL PRIMARY KEY,
+    detail_id INT NOT NULL REFERENCES transaction_details(id) ON DELETE CASCADE,
+    product_id INT NOT NULL REFERENCES products(id),
+    product_name VARCHAR(255) NOT NULL,
+    choice_group_id INT,
+    quantity INT NOT NULL,
+    revenue INT NOT NULL,
+    cost INT NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_transaction_detail_components_detail ON transaction_detail_components (detail_id);
@@ -0,0 +1,36 @@
+CREATE TABLE IF NOT EXISTS modifier_groups (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    product_id INT REFERENCES products(id),
+    category_id INT REFERENCES categories(id),
+    min_select INT NOT NULL DEFAULT 0,
+    max_select INT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMP,
+    CHECK ((product_id IS NULL) <> (category_id IS NULL))
+);
+
+CREATE TABLE IF NOT EXISTS modifiers (
+    id SERIAL PRIMARY KEY,
+    group_id INT NOT NULL REFERENCES modifier_groups(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    price_delta INT NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_modifier_groups_product ON modifier_groups (product_id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_modifier_groups_category ON modifier_groups (category_id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_modifiers_group ON modifiers (group_id);
+
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS note VARCHAR(255) NOT NULL DEFAULT '';
+
+-- Modifiers chosen on a sold line, copied so menu changes do not rewrite history
+CREATE TABLE IF NOT EXISTS transaction_detail_modifiers (
+    id SERIAL PRIMARY KEY,
+    detail_id INT NOT NULL REFERENCES transaction_details(id) ON DELETE CASCADE,
+    modifier_id INT NOT NULL,
+    group_name VARCHAR(100) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    price_delta INT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_transaction_detail_modifiers_detail ON transaction_detail_modifiers (detail_id);
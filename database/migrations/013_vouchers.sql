@@ -0,0 +1,35 @@
+CREATE TABLE IF NOT EXISTS vouchers (
+    id SERIAL PRIMARY KEY,
+    code VARCHAR(32) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    type VARCHAR(20) NOT NULL,
+    value INT NOT NULL,
+    max_discount INT NOT NULL DEFAULT 0,
+    min_spend INT NOT NULL DEFAULT 0,
+    max_uses INT,
+    per_customer_limit INT,
+    expires_at TIMESTAMPTZ,
+    uses INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_vouchers_code ON vouchers (code) WHERE deleted_at IS NULL;
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS voucher_id INT REFERENCES vouchers(id);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS voucher_code VARCHAR(32) NOT NULL DEFAULT '';
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS voucher_discount INT NOT NULL DEFAULT 0;
+
+-- One row per use; a void or refund gives the use back and sets reversed_at
+CREATE TABLE IF NOT EXISTS voucher_redemptions (
+    id SERIAL PRIMARY KEY,
+    voucher_id INT NOT NULL REFERENCES vouchers(id),
+    transaction_id INT NOT NULL REFERENCES transactions(id),
+    customer_id INT REFERENCES customers(id),
+    discount INT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    reversed_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_voucher_redemptions_voucher ON voucher_redemptions (voucher_id, customer_id);
+CREATE INDEX IF NOT EXISTS idx_voucher_redemptions_transaction ON voucher_redemptions (transaction_id);
@@ -0,0 +1,17 @@
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS credit_limit INT NOT NULL DEFAULT 0;
+
+-- Repayments of kasbon (pay later) sales; what a customer owes is their
+-- completed kasbon sales less these
+CREATE TABLE IF NOT EXISTS receivable_payments (
+    id SERIAL PRIMARY KEY,
+    customer_id INT NOT NULL REFERENCES customers(id),
+    amount INT NOT NULL CHECK (amount > 0),
+    payment_method VARCHAR(20) NOT NULL,
+    shift_id INT REFERENCES shifts(id),
+    cashier_id VARCHAR(64) NOT NULL DEFAULT '',
+    note VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_receivable_payments_customer ON receivable_payments (customer_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_receivable_payments_shift ON receivable_payments (shift_id) WHERE shift_id IS NOT NULL;
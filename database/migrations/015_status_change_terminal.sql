@@ -0,0 +1,9 @@
+-- Terminal a void or refund was made on, so it is accounted to that register
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS status_changed_terminal VARCHAR(64) NOT NULL DEFAULT '';
+
+UPDATE transactions t
+SET status_changed_terminal = s.terminal_id
+FROM shifts s
+WHERE s.id = t.status_shift_id AND t.status_changed_terminal = '';
+
+CREATE INDEX IF NOT EXISTS idx_transactions_status_changed ON transactions (status_changed_at) WHERE status_changed_at IS NOT NULL;
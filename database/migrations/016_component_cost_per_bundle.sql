@@ -0,0 +1,6 @@
+-- Bundle component cost was per unit of the component; store it per bundle,
+-- the unit of the line's own cost. Component quantity covers the whole line.
+UPDATE transaction_detail_components c
+SET cost = c.cost * c.quantity / d.quantity
+FROM transaction_details d
+WHERE d.id = c.detail_id AND d.quantity > 0;
@@ -0,0 +1,48 @@
+package database
+
+import (
+	"context"
+
+	"kasir-api/tracing"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// queryTracer opens a span around every SQL statement pgx executes.
+type queryTracer struct{}
+
+func (queryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	ctx, _ = tracing.Start(ctx, spanName(data.SQL),
+		semconv.DBSystemPostgreSQL,
+		semconv.DBQueryText(data.SQL),
+		attribute.Int("db.args", len(data.Args)),
+	)
+	return ctx
+}
+
+func (queryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(attribute.Int64("db.rows_affected", data.CommandTag.RowsAffected()))
+	tracing.End(span, data.Err)
+}
+
+// spanName uses the SQL verb, e.g. "SELECT" or "INSERT", to keep span names low-cardinality.
+func spanName(sql string) string {
+	start := -1
+	for i, c := range sql {
+		isLetter := (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z')
+		if isLetter && start < 0 {
+			start = i
+		}
+		if !isLetter && start >= 0 {
+			return "db " + sql[start:i]
+		}
+	}
+	if start >= 0 {
+		return "db " + sql[start:]
+	}
+	return "db query"
+}
@@ -0,0 +1,81 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"kasir-api/tracing"
+	"kasir-api/tracing/tracingtest"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel/codes"
+)
+
+func TestQueryTracerOpensChildSpans(t *testing.T) {
+	exporter := tracingtest.Record(t)
+	tracer := queryTracer{}
+
+	ctx, parent := tracing.Start(context.Background(), "TransactionService.Checkout")
+	queries := []struct {
+		sql string
+		tag string
+	}{
+		{"SELECT id, stock FROM products WHERE id = $1 FOR UPDATE", "SELECT 1"},
+		{"\n\t\tINSERT INTO transactions (total_amount) VALUES ($1) RETURNING id", "INSERT 0 1"},
+	}
+	for _, q := range queries {
+		queryCtx := tracer.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{SQL: q.sql, Args: []any{1}})
+		tracer.TraceQueryEnd(queryCtx, nil, pgx.TraceQueryEndData{CommandTag: pgconn.NewCommandTag(q.tag)})
+	}
+	parent.End()
+
+	for _, name := range []string{"db SELECT", "db INSERT"} {
+		span := tracingtest.Find(t, exporter, name)
+		if span.Parent.SpanID() != parent.SpanContext().SpanID() {
+			t.Errorf("%s: parent = %s, want the checkout span", name, span.Parent.SpanID())
+		}
+		if got := tracingtest.Attribute(span, "db.system"); got != "postgresql" {
+			t.Errorf("%s: db.system = %v, want postgresql", name, got)
+		}
+		if got := tracingtest.Attribute(span, "db.args"); got != int64(1) {
+			t.Errorf("%s: db.args = %v, want 1", name, got)
+		}
+		if got := tracingtest.Attribute(span, "db.rows_affected"); got != int64(1) {
+			t.Errorf("%s: db.rows_affected = %v, want 1", name, got)
+		}
+	}
+}
+
+func TestQueryTracerRecordsErrors(t *testing.T) {
+	exporter := tracingtest.Record(t)
+	tracer := queryTracer{}
+
+	ctx := tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{SQL: "UPDATE products SET stock = 0"})
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{Err: errors.New("deadlock detected")})
+
+	span := tracingtest.Find(t, exporter, "db UPDATE")
+	if span.Status.Code != codes.Error || span.Status.Description != "deadlock detected" {
+		t.Errorf("status = %v %q, want error \"deadlock detected\"", span.Status.Code, span.Status.Description)
+	}
+}
+
+func TestSpanName(t *testing.T) {
+	tests := []struct {
+		sql  string
+		want string
+	}{
+		{"SELECT 1", "db SELECT"},
+		{"\n\t\tWITH paid AS (SELECT 1) SELECT * FROM paid", "db WITH"},
+		{"insert into products values (1)", "db insert"},
+		{"COMMIT", "db COMMIT"},
+		{"  -- 1", "db query"},
+		{"", "db query"},
+	}
+	for _, tt := range tests {
+		if got := spanName(tt.sql); got != tt.want {
+			t.Errorf("spanName(%q) = %q, want %q", tt.sql, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"encoding/json"
+	"kasir-api/apperror"
+	"kasir-api/models"
+	"kasir-api/services"
+	"net/http"
+	"strconv"
+)
+
+type AuditHandler struct {
+	service *services.AuditService
+}
+
+func NewAuditHandler(service *services.AuditService) *AuditHandler {
+	return &AuditHandler{service: service}
+}
+
+func (h *AuditHandler) List(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := models.AuditFilter{
+		Entity: query.Get("entity"),
+		Actor:  query.Get("actor"),
+	}
+
+	var err error
+	if value := query.Get("entity_id"); value != "" {
+		if filter.EntityID, err = strconv.Atoi(value); err != nil {
+			writeError(w, r, apperror.Validation("entity_id", "entity_id must be an integer"))
+			return
+		}
+	}
+	if value := query.Get("limit"); value != "" {
+		if filter.Limit, err = strconv.Atoi(value); err != nil {
+			writeError(w, r, apperror.Validation("limit", "limit must be an integer"))
+			return
+		}
+	}
+
+	entries, err := h.service.List(r.Context(), filter, query.Get("start_date"), query.Get("end_date"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
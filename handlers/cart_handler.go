@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"kasir-api/apperror"
+	"kasir-api/models"
+	"kasir-api/services"
+)
+
+type CartHandler struct {
+	service *services.CartService
+}
+
+func NewCartHandler(service *services.CartService) *CartHandler {
+	return &CartHandler{service: service}
+}
+
+func (h *CartHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	carts, err := h.service.GetAll(r.Context(), query.Get("status"), query.Get("terminal_id"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(carts)
+}
+
+func (h *CartHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateCartRequest
+	if r.ContentLength != 0 {
+		if err := decodeJSON(r, &req); err != nil {
+			writeError(w, r, err)
+			return
+		}
+	}
+
+	cart, err := h.service.Create(r.Context(), req, attributionFromRequest(r))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(cart)
+}
+
+func (h *CartHandler) respondCart(w http.ResponseWriter, r *http.Request, cart *models.Cart, err error) {
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(cart)
+}
+
+// get cart with live prices
+func (h *CartHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, r, apperror.BadRequest("invalid cart ID"))
+		return
+	}
+
+	cart, err := h.service.GetByID(r.Context(), id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(cart)
+}
+
+func (h *CartHandler) AddItem(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, r, apperror.BadRequest("invalid cart ID"))
+		return
+	}
+
+	var req models.CartItemRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	cart, err := h.service.AddItem(r.Context(), id, req)
+	h.respondCart(w, r, cart, err)
+}
+
+func (h *CartHandler) UpdateItem(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, r, apperror.BadRequest("invalid cart ID"))
+		return
+	}
+
+	productID, err := strconv.Atoi(r.PathValue("product_id"))
+	if err != nil {
+		writeError(w, r, apperror.BadRequest("invalid product ID"))
+		return
+	}
+
+	var req models.CartItemRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	req.ProductID = productID
+	cart, err := h.service.UpdateItem(r.Context(), id, req)
+	h.respondCart(w, r, cart, err)
+}
+
+func (h *CartHandler) RemoveItem(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, r, apperror.BadRequest("invalid cart ID"))
+		return
+	}
+
+	productID, err := strconv.Atoi(r.PathValue("product_id"))
+	if err != nil {
+		writeError(w, r, apperror.BadRequest("invalid product ID"))
+		return
+	}
+
+	cart, err := h.service.RemoveItem(r.Context(), id, productID)
+	h.respondCart(w, r, cart, err)
+}
+
+// park an active cart
+func (h *CartHandler) Hold(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, r, apperror.BadRequest("invalid cart ID"))
+		return
+	}
+
+	cart, err := h.service.Hold(r.Context(), id)
+	h.respondCart(w, r, cart, err)
+}
+
+func (h *CartHandler) Resume(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, r, apperror.BadRequest("invalid cart ID"))
+		return
+	}
+
+	cart, err := h.service.Resume(r.Context(), id)
+	h.respondCart(w, r, cart, err)
+}
+
+func (h *CartHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, r, apperror.BadRequest("invalid cart ID"))
+		return
+	}
+
+	if err := h.service.Cancel(r.Context(), id); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *CartHandler) Checkout(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, r, apperror.BadRequest("invalid cart ID"))
+		return
+	}
+
+	var req models.CartCheckoutRequest
+	if r.ContentLength != 0 {
+		if err := decodeJSON(r, &req); err != nil {
+			writeError(w, r, err)
+			return
+		}
+	}
+
+	transaction, err := h.service.Checkout(r.Context(), id, req, attributionFromRequest(r))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(transaction)
+}
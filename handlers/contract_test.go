@@ -0,0 +1,451 @@
+package handlers
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"kasir-api/database/dbtest"
+	"kasir-api/metrics"
+	"kasir-api/models"
+	"kasir-api/openapi"
+	"kasir-api/repositories"
+	"kasir-api/services"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// newTestRouter wires every handler like main does, on db.
+func newTestRouter(t *testing.T, db *sql.DB) *router {
+	t.Helper()
+
+	categoryRepo := repositories.NewCategoryRepository(db)
+	productRepo := repositories.NewProductRepository(db)
+	shiftRepo := repositories.NewShiftRepository(db)
+	customerRepo := repositories.NewCustomerRepository(db)
+	loyalty := models.LoyaltyProgram{RupiahPerPoint: 10000, PointValue: 100}
+
+	productService := services.NewProductService(productRepo, categoryRepo, repositories.NewPriceRepository(db), repositories.NewBundleRepository(db))
+	transactionService := services.NewTransactionService(repositories.NewTransactionRepository(db), shiftRepo, false, time.UTC, loyalty)
+
+	h := NewRouter(Handlers{
+		Health:      NewHealthHandler(db, "test", time.Second),
+		Category:    NewCategoryHandler(services.NewCategoryService(categoryRepo)),
+		Product:     NewProductHandler(productService),
+		PriceRule:   NewPriceRuleHandler(services.NewPriceRuleService(repositories.NewPriceRuleRepository(db), productRepo, categoryRepo)),
+		Modifier:    NewModifierHandler(services.NewModifierService(repositories.NewModifierRepository(db), productRepo, categoryRepo)),
+		Customer:    NewCustomerHandler(services.NewCustomerService(customerRepo, loyalty)),
+		Voucher:     NewVoucherHandler(services.NewVoucherService(repositories.NewVoucherRepository(db))),
+		Receivable:  NewReceivableHandler(services.NewReceivableService(repositories.NewReceivableRepository(db), customerRepo, shiftRepo, time.UTC)),
+		Transaction: NewTransactionHandler(transactionService),
+		Shift:       NewShiftHandler(services.NewShiftService(shiftRepo)),
+		Cart:        NewCartHandler(services.NewCartService(repositories.NewCartRepository(db), productRepo, transactionService, 0)),
+		Audit:       NewAuditHandler(services.NewAuditService(repositories.NewAuditRepository(db))),
+		Metrics:     metrics.Handler(),
+	}, RouterOptions{})
+	return h.(*router)
+}
+
+// newOfflineRouter serves routes that answer before touching the database;
+// the pool never connects.
+func newOfflineRouter(t *testing.T) *router {
+	t.Helper()
+
+	db, err := sql.Open("pgx", "postgres://127.0.0.1:1/kasir?connect_timeout=1")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return newTestRouter(t, db)
+}
+
+// contract checks responses of a router against the document it publishes.
+type contract struct {
+	t      *testing.T
+	rt     *router
+	doc    *openapi.Document
+	header http.Header // sent with every request
+}
+
+func newContract(t *testing.T, rt *router) *contract {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	rt.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /openapi.json = %d", rec.Code)
+	}
+	var doc openapi.Document
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("decode document: %v", err)
+	}
+	return &contract{t: t, rt: rt, doc: &doc, header: http.Header{}}
+}
+
+// do sends the request and fails the test unless the status is the one
+// wanted and the response is documented for the matched route: status,
+// content type and body schema.
+func (c *contract) do(method, target string, body any, want int) map[string]any {
+	c.t.Helper()
+
+	var payload bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&payload).Encode(body); err != nil {
+			c.t.Fatalf("encode body: %v", err)
+		}
+	}
+	req := httptest.NewRequest(method, target, &payload)
+	req.Header.Set("Content-Type", "application/json")
+	for key, values := range c.header {
+		req.Header[key] = values
+	}
+	rec := httptest.NewRecorder()
+	c.rt.ServeHTTP(rec, req)
+
+	if rec.Code != want {
+		c.t.Fatalf("%s %s = %d, want %d: %s", method, target, rec.Code, want, rec.Body)
+	}
+
+	_, pattern := c.rt.mux.Handler(httptest.NewRequest(method, target, nil))
+	if pattern == "" {
+		c.t.Fatalf("%s %s matches no route", method, target)
+	}
+	_, path, _ := strings.Cut(pattern, " ")
+	item, ok := c.doc.Paths[path]
+	if !ok {
+		c.t.Fatalf("%s is not documented", path)
+	}
+	op, ok := (*item)[strings.ToLower(method)]
+	if !ok {
+		c.t.Fatalf("%s %s is not documented", method, path)
+	}
+	response, ok := op.Responses[strconv.Itoa(rec.Code)]
+	if !ok {
+		c.t.Fatalf("%s %s answered %d, which is not documented", method, path, rec.Code)
+	}
+
+	if len(response.Content) == 0 {
+		if rec.Body.Len() > 0 {
+			c.t.Errorf("%s %s %d: body %q, documented without content", method, path, rec.Code, rec.Body)
+		}
+		return nil
+	}
+	contentType, _, _ := strings.Cut(rec.Header().Get("Content-Type"), ";")
+	media, ok := response.Content[contentType]
+	if !ok {
+		c.t.Fatalf("%s %s %d: content type %q is not documented", method, path, rec.Code, contentType)
+	}
+
+	var decoded any
+	if err := json.Unmarshal(rec.Body.Bytes(), &decoded); err != nil {
+		c.t.Fatalf("%s %s %d: decode body: %v", method, path, rec.Code, err)
+	}
+	for _, problem := range c.conform(media.Schema, decoded, "body") {
+		c.t.Errorf("%s %s %d: %s", method, path, rec.Code, problem)
+	}
+	object, _ := decoded.(map[string]any)
+	return object
+}
+
+// conform lists where value does not match schema. Properties may be
+// missing (omitempty), but every property sent must be documented.
+func (c *contract) conform(schema *openapi.Schema, value any, at string) []string {
+	if schema.Ref != "" {
+		if value == nil {
+			// a $ref cannot be marked nullable in 3.0, so pointers to structs are not
+			return nil
+		}
+		name := strings.TrimPrefix(schema.Ref, "#/components/schemas/")
+		resolved, ok := c.doc.Components.Schemas[name]
+		if !ok {
+			return []string{fmt.Sprintf("%s: schema %s is not in the components", at, name)}
+		}
+		return c.conform(resolved, value, at)
+	}
+	if value == nil {
+		if schema.Nullable || schema.Type == "" {
+			return nil
+		}
+		return []string{fmt.Sprintf("%s: null, want %s", at, schema.Type)}
+	}
+
+	mismatch := []string{fmt.Sprintf("%s: %T, want %s", at, value, schema.Type)}
+	switch schema.Type {
+	case "":
+		return nil
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return mismatch
+		}
+	case "string":
+		if _, ok := value.(string); !ok {
+			return mismatch
+		}
+	case "number":
+		if _, ok := value.(float64); !ok {
+			return mismatch
+		}
+	case "integer":
+		if n, ok := value.(float64); !ok || n != math.Trunc(n) {
+			return mismatch
+		}
+	case "array":
+		items, ok := value.([]any)
+		if !ok {
+			return mismatch
+		}
+		var problems []string
+		for i, item := range items {
+			problems = append(problems, c.conform(schema.Items, item, fmt.Sprintf("%s[%d]", at, i))...)
+		}
+		return problems
+	case "object":
+		object, ok := value.(map[string]any)
+		if !ok {
+			return mismatch
+		}
+		var problems []string
+		for key, property := range object {
+			propertySchema := schema.AdditionalProperties
+			if schema.Properties != nil {
+				propertySchema = schema.Properties[key]
+			}
+			if propertySchema == nil {
+				problems = append(problems, fmt.Sprintf("%s.%s is not documented", at, key))
+				continue
+			}
+			problems = append(problems, c.conform(propertySchema, property, at+"."+key)...)
+		}
+		return problems
+	}
+	return nil
+}
+
+// registeredRoutes reads the patterns registered in routes_v1.go from its
+// source, so the check does not rely on the code that builds the document.
+func registeredRoutes(t *testing.T) []string {
+	t.Helper()
+
+	file, err := parser.ParseFile(token.NewFileSet(), "routes_v1.go", nil, 0)
+	if err != nil {
+		t.Fatalf("parse routes_v1.go: %v", err)
+	}
+
+	var patterns []string
+	ast.Inspect(file, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || len(call.Args) == 0 {
+			return true
+		}
+		selector, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok || (selector.Sel.Name != "handle" && selector.Sel.Name != "handleFunc") {
+			return true
+		}
+		literal, ok := call.Args[0].(*ast.BasicLit)
+		if !ok || literal.Kind != token.STRING {
+			t.Errorf("%s: route pattern is not a string literal", selector.Sel.Name)
+			return true
+		}
+		pattern, err := strconv.Unquote(literal.Value)
+		if err != nil {
+			t.Fatalf("unquote %s: %v", literal.Value, err)
+		}
+		patterns = append(patterns, pattern)
+		return true
+	})
+	return patterns
+}
+
+func TestContractEveryRouteIsDocumented(t *testing.T) {
+	c := newContract(t, newOfflineRouter(t))
+
+	patterns := registeredRoutes(t)
+	if len(patterns) == 0 {
+		t.Fatal("no routes found in routes_v1.go")
+	}
+	for _, pattern := range patterns {
+		method, path, _ := strings.Cut(pattern, " ")
+		item, ok := c.doc.Paths["/api/v1"+path]
+		if !ok {
+			t.Errorf("%s: path /api/v1%s is not documented", pattern, path)
+			continue
+		}
+		if _, ok := (*item)[strings.ToLower(method)]; !ok {
+			t.Errorf("%s: method is not documented for /api/v1%s", pattern, path)
+		}
+	}
+}
+
+func TestContractEveryDocumentedPathIsServed(t *testing.T) {
+	c := newContract(t, newOfflineRouter(t))
+
+	for path, item := range c.doc.Paths {
+		target := path
+		for {
+			start := strings.Index(target, "{")
+			if start < 0 {
+				break
+			}
+			end := strings.Index(target[start:], "}")
+			target = target[:start] + "1" + target[start+end+1:]
+		}
+		for method := range *item {
+			req := httptest.NewRequest(strings.ToUpper(method), target, nil)
+			if _, pattern := c.rt.mux.Handler(req); pattern == "" {
+				t.Errorf("%s %s is documented but not served", strings.ToUpper(method), path)
+			}
+		}
+	}
+}
+
+func TestContractValidationProblem(t *testing.T) {
+	c := newContract(t, newOfflineRouter(t))
+
+	problem := c.do(http.MethodPost, "/api/v1/products", map[string]any{"name": "", "price": 0}, http.StatusUnprocessableEntity)
+	if problem["code"] != "validation" {
+		t.Errorf("code = %v, want validation", problem["code"])
+	}
+	if errors, _ := problem["errors"].([]any); len(errors) == 0 {
+		t.Error("errors is empty, want the failing fields")
+	}
+}
+
+func TestContractBadRequestProblem(t *testing.T) {
+	c := newContract(t, newOfflineRouter(t))
+
+	problem := c.do(http.MethodGet, "/api/v1/products/abc", nil, http.StatusBadRequest)
+	if problem["code"] != "bad_request" {
+		t.Errorf("code = %v, want bad_request", problem["code"])
+	}
+	c.do(http.MethodPost, "/api/v1/checkout", map[string]any{"items": []any{}}, http.StatusUnprocessableEntity)
+}
+
+func TestContractCreateProduct(t *testing.T) {
+	c := newContract(t, newTestRouter(t, dbtest.Open(t)))
+
+	category := c.do(http.MethodPost, "/api/v1/categories", map[string]any{"name": "Minuman"}, http.StatusCreated)
+	product := c.do(http.MethodPost, "/api/v1/products", map[string]any{
+		"name":        "Es Teh",
+		"price":       5000,
+		"stock":       20,
+		"category_id": category["id"],
+	}, http.StatusCreated)
+	if product["name"] != "Es Teh" {
+		t.Errorf("name = %v, want Es Teh", product["name"])
+	}
+
+	c.do(http.MethodGet, fmt.Sprintf("/api/v1/products/%v", product["id"]), nil, http.StatusOK)
+	c.do(http.MethodGet, "/api/v1/products", nil, http.StatusOK)
+	c.do(http.MethodPost, "/api/v1/products", map[string]any{
+		"name":        "Es Teh",
+		"price":       5000,
+		"category_id": category["id"],
+	}, http.StatusConflict)
+	c.do(http.MethodGet, "/api/v1/products/999999", nil, http.StatusNotFound)
+}
+
+// newSellingContract returns a contract on the test database whose requests
+// come from a cashier at a terminal, and a function adding a product to sell.
+func newSellingContract(t *testing.T) (*contract, func(name string, price, stock int) map[string]any) {
+	t.Helper()
+
+	c := newContract(t, newTestRouter(t, dbtest.Open(t)))
+	c.header.Set("X-Cashier-ID", "kasir-1")
+	c.header.Set("X-Terminal-ID", "T1")
+
+	category := c.do(http.MethodPost, "/api/v1/categories", map[string]any{"name": "Menu"}, http.StatusCreated)
+	createProduct := func(name string, price, stock int) map[string]any {
+		t.Helper()
+		return c.do(http.MethodPost, "/api/v1/products", map[string]any{
+			"name": name, "price": price, "stock": stock, "category_id": category["id"],
+		}, http.StatusCreated)
+	}
+	return c, createProduct
+}
+
+func TestContractCheckout(t *testing.T) {
+	c, createProduct := newSellingContract(t)
+	product := createProduct("Kopi Susu", 18000, 10)
+
+	transaction := c.do(http.MethodPost, "/api/v1/checkout", map[string]any{
+		"items":          []any{map[string]any{"product_id": product["id"], "quantity": 2}},
+		"payment_method": "cash",
+	}, http.StatusOK)
+	if transaction["total_amount"] != float64(36000) {
+		t.Errorf("total_amount = %v, want 36000", transaction["total_amount"])
+	}
+
+	c.do(http.MethodGet, fmt.Sprintf("/api/v1/transactions/%v", transaction["id"]), nil, http.StatusOK)
+	c.do(http.MethodGet, "/api/v1/report/sales-summary", nil, http.StatusOK)
+	c.do(http.MethodGet, "/api/v1/report/cashiers", nil, http.StatusOK)
+	c.do(http.MethodGet, "/api/v1/report/terminals", nil, http.StatusOK)
+	c.do(http.MethodPost, "/api/v1/checkout", map[string]any{
+		"items": []any{map[string]any{"product_id": product["id"], "quantity": 100}},
+	}, http.StatusConflict)
+}
+
+func TestContractCartCheckout(t *testing.T) {
+	c, createProduct := newSellingContract(t)
+	product := createProduct("Roti Bakar", 15000, 10)
+
+	cart := c.do(http.MethodPost, "/api/v1/carts", nil, http.StatusCreated)
+	cartPath := fmt.Sprintf("/api/v1/carts/%v", cart["id"])
+	cart = c.do(http.MethodPost, cartPath+"/items", map[string]any{"product_id": product["id"], "quantity": 2}, http.StatusOK)
+	if cart["total_amount"] != float64(30000) {
+		t.Errorf("cart total_amount = %v, want 30000", cart["total_amount"])
+	}
+	c.do(http.MethodPost, cartPath+"/hold", nil, http.StatusOK)
+	c.do(http.MethodPost, cartPath+"/resume", nil, http.StatusOK)
+
+	transaction := c.do(http.MethodPost, cartPath+"/checkout", map[string]any{"payment_method": "qris"}, http.StatusOK)
+	if transaction["total_amount"] != float64(30000) {
+		t.Errorf("total_amount = %v, want 30000", transaction["total_amount"])
+	}
+
+	cart = c.do(http.MethodGet, cartPath, nil, http.StatusOK)
+	if cart["status"] != "converted" || cart["transaction_id"] != transaction["id"] {
+		t.Errorf("cart status = %v, transaction_id = %v, want converted and %v", cart["status"], cart["transaction_id"], transaction["id"])
+	}
+	c.do(http.MethodPost, cartPath+"/checkout", nil, http.StatusConflict)
+}
+
+func TestContractReceivableRepay(t *testing.T) {
+	c, createProduct := newSellingContract(t)
+	product := createProduct("Beras 5kg", 70000, 10)
+
+	customer := c.do(http.MethodPost, "/api/v1/customers", map[string]any{"name": "Bu Sari"}, http.StatusCreated)
+	customerPath := fmt.Sprintf("/api/v1/customers/%v", customer["id"])
+	c.do(http.MethodPut, customerPath+"/credit-limit", map[string]any{"credit_limit": 100000}, http.StatusOK)
+
+	c.do(http.MethodPost, "/api/v1/checkout", map[string]any{
+		"items":          []any{map[string]any{"product_id": product["id"], "quantity": 1}},
+		"payment_method": "kasbon",
+		"customer_id":    customer["id"],
+	}, http.StatusOK)
+
+	payment := c.do(http.MethodPost, customerPath+"/receivables/payments", map[string]any{"amount": 30000}, http.StatusCreated)
+	if payment["payment_method"] != "cash" {
+		t.Errorf("payment_method = %v, want cash", payment["payment_method"])
+	}
+
+	account := c.do(http.MethodGet, customerPath+"/receivables", nil, http.StatusOK)
+	if account["balance"] != float64(40000) {
+		t.Errorf("balance = %v, want 40000", account["balance"])
+	}
+	aging := c.do(http.MethodGet, "/api/v1/report/receivables-aging", nil, http.StatusOK)
+	if aging["total"] != float64(40000) {
+		t.Errorf("aging total = %v, want 40000", aging["total"])
+	}
+	c.do(http.MethodPost, customerPath+"/receivables/payments", map[string]any{"amount": 0}, http.StatusUnprocessableEntity)
+}
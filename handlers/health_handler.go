@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"kasir-api/database"
+	"kasir-api/models"
+)
+
+type HealthHandler struct {
+	db           *sql.DB
+	version      string
+	startedAt    time.Time
+	checkTimeout time.Duration
+}
+
+func NewHealthHandler(db *sql.DB, version string, checkTimeout time.Duration) *HealthHandler {
+	return &HealthHandler{db: db, version: version, startedAt: time.Now(), checkTimeout: checkTimeout}
+}
+
+// Liveness only tells the orchestrator the process is up; it never touches dependencies.
+func (h *HealthHandler) HandleLive(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.HealthCheck{Status: "OK", Message: "API Running"})
+}
+
+// Readiness pings the database and checks the schema version; 503 when either fails.
+func (h *HealthHandler) HandleReady(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), h.checkTimeout)
+	defer cancel()
+
+	uptime := time.Since(h.startedAt)
+	report := models.ReadinessReport{
+		Status:        "OK",
+		Version:       h.version,
+		Uptime:        uptime.Round(time.Second).String(),
+		UptimeSeconds: int64(uptime.Seconds()),
+		Checks: map[string]models.HealthCheck{
+			"database":   h.checkDatabase(ctx),
+			"migrations": h.checkMigrations(ctx),
+		},
+	}
+
+	stats := h.db.Stats()
+	report.DBPool = models.DBPoolStats{
+		MaxOpenConnections: stats.MaxOpenConnections,
+		OpenConnections:    stats.OpenConnections,
+		InUse:              stats.InUse,
+		Idle:               stats.Idle,
+		WaitCount:          stats.WaitCount,
+		WaitDurationMs:     stats.WaitDuration.Milliseconds(),
+	}
+
+	status := http.StatusOK
+	for _, check := range report.Checks {
+		if check.Status != "OK" {
+			report.Status = "UNAVAILABLE"
+			status = http.StatusServiceUnavailable
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(report)
+}
+
+func (h *HealthHandler) checkDatabase(ctx context.Context) models.HealthCheck {
+	if err := h.db.PingContext(ctx); err != nil {
+		return models.HealthCheck{Status: "FAIL", Message: "database unreachable"}
+	}
+	return models.HealthCheck{Status: "OK"}
+}
+
+func (h *HealthHandler) checkMigrations(ctx context.Context) models.HealthCheck {
+	expected, err := database.ExpectedVersion()
+	if err != nil {
+		return models.HealthCheck{Status: "FAIL", Message: "cannot read embedded migrations"}
+	}
+
+	current, err := database.CurrentVersion(ctx, h.db)
+	if err != nil {
+		return models.HealthCheck{Status: "FAIL", Message: "cannot read schema version"}
+	}
+	if current != expected {
+		return models.HealthCheck{Status: "FAIL", Message: fmt.Sprintf("schema at version %d, expected %d", current, expected)}
+	}
+
+	return models.HealthCheck{Status: "OK", Message: fmt.Sprintf("version %d", current)}
+}
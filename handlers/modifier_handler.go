@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"encoding/json"
+	"kasir-api/apperror"
+	"kasir-api/models"
+	"kasir-api/services"
+	"net/http"
+	"strconv"
+)
+
+type ModifierHandler struct {
+	service *services.ModifierService
+}
+
+func NewModifierHandler(service *services.ModifierService) *ModifierHandler {
+	return &ModifierHandler{service: service}
+}
+
+func (h *ModifierHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	includeDeleted, err := includeDeletedParam(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	groups, err := h.service.GetAll(r.Context(), includeDeleted)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(groups)
+}
+
+func (h *ModifierHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var group models.ModifierGroup
+	if err := decodeJSON(r, &group); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if err := h.service.Create(r.Context(), &group); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(group)
+}
+
+func (h *ModifierHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, r, apperror.BadRequest("invalid modifier group ID"))
+		return
+	}
+
+	includeDeleted, err := includeDeletedParam(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	group, err := h.service.GetByID(r.Context(), id, includeDeleted)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(group)
+}
+
+func (h *ModifierHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, r, apperror.BadRequest("invalid modifier group ID"))
+		return
+	}
+
+	var group models.ModifierGroup
+	if err := decodeJSON(r, &group); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	group.ID = id
+	if err := h.service.Update(r.Context(), &group); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(group)
+}
+
+func (h *ModifierHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, r, apperror.BadRequest("invalid modifier group ID"))
+		return
+	}
+
+	if err := h.service.Delete(r.Context(), id); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *ModifierHandler) ForProduct(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, r, apperror.BadRequest("invalid product ID"))
+		return
+	}
+
+	groups, err := h.service.ForProduct(r.Context(), id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(groups)
+}
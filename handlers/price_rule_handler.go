@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"encoding/json"
+	"kasir-api/apperror"
+	"kasir-api/models"
+	"kasir-api/services"
+	"net/http"
+	"strconv"
+)
+
+type PriceRuleHandler struct {
+	service *services.PriceRuleService
+}
+
+func NewPriceRuleHandler(service *services.PriceRuleService) *PriceRuleHandler {
+	return &PriceRuleHandler{service: service}
+}
+
+func (h *PriceRuleHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	includeDeleted, err := includeDeletedParam(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	rules, err := h.service.GetAll(r.Context(), includeDeleted)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rules)
+}
+
+func (h *PriceRuleHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var rule models.PriceRule
+	if err := decodeJSON(r, &rule); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if err := h.service.Create(r.Context(), &rule); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(rule)
+}
+
+func (h *PriceRuleHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, r, apperror.BadRequest("invalid price rule ID"))
+		return
+	}
+
+	includeDeleted, err := includeDeletedParam(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	rule, err := h.service.GetByID(r.Context(), id, includeDeleted)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rule)
+}
+
+func (h *PriceRuleHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, r, apperror.BadRequest("invalid price rule ID"))
+		return
+	}
+
+	var rule models.PriceRule
+	if err := decodeJSON(r, &rule); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	rule.ID = id
+	if err := h.service.Update(r.Context(), &rule); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rule)
+}
+
+func (h *PriceRuleHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, r, apperror.BadRequest("invalid price rule ID"))
+		return
+	}
+
+	if err := h.service.Delete(r.Context(), id); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	"kasir-api/apperror"
+	"kasir-api/middleware"
+	"kasir-api/ratelimit"
+)
+
+// rateLimit gives every client its own bucket on route. Limiter failures let
+// the request through rather than take the API down with the store.
+func rateLimit(limiter ratelimit.Limiter, route string, limit ratelimit.Limit, clients clients) middleware.Middleware {
+	return func(next http.Handler) http.Handler {
+		if limit.Unlimited() {
+			return next
+		}
+
+		policy := limit.String()
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			result, err := limiter.Allow(r.Context(), route+" "+clients.key(r), limit)
+			if err != nil {
+				slog.WarnContext(r.Context(), "rate limiter unavailable", slog.Any("error", err))
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			header := w.Header()
+			header.Set("RateLimit-Policy", policy)
+			header.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+			header.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			header.Set("RateLimit-Reset", ceilSeconds(result.Reset))
+			if !result.Allowed {
+				header.Set("Retry-After", ceilSeconds(result.RetryAfter))
+				writeError(w, r, apperror.New(apperror.CodeRateLimited, "rate limit exceeded, retry in %s seconds", ceilSeconds(result.RetryAfter)))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// clients tells callers apart for rate limiting.
+type clients struct {
+	trustedProxies []netip.Prefix
+	identity       func(*http.Request) string
+}
+
+// key identifies the caller by its authenticated identity when it has one,
+// otherwise by client IP. X-API-Key and X-Cashier-ID are not authenticated,
+// so keying by them would let a client that sends a new value on every
+// request get a fresh bucket each time.
+func (c clients) key(r *http.Request) string {
+	if c.identity != nil {
+		if id := c.identity(r); id != "" {
+			return "id:" + id
+		}
+	}
+	return "ip:" + c.ip(r)
+}
+
+// ip is the remote address or, when that is a trusted proxy, the address the
+// proxies forwarded for: the right-most X-Forwarded-For entry that is not a
+// trusted proxy itself. Entries left of it were written by the client.
+func (c clients) ip(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil || !c.trusted(addr) {
+		return host
+	}
+
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+		addr = hop
+		if !c.trusted(hop) {
+			break
+		}
+	}
+	return addr.Unmap().String()
+}
+
+func (c clients) trusted(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, proxy := range c.trustedProxies {
+		if proxy.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
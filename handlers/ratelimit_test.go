@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"kasir-api/ratelimit"
+)
+
+func TestRateLimitIgnoresUnauthenticatedHeaders(t *testing.T) {
+	limit, err := ratelimit.ParseLimit("2/1m")
+	if err != nil {
+		t.Fatalf("parse limit: %v", err)
+	}
+	h := rateLimit(ratelimit.NewMemory(), "GET /report/sales-summary", limit, clients{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	codes := make([]int, 0, 3)
+	for i := range 3 {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/report/sales-summary", nil)
+		req.RemoteAddr = "203.0.113.7:5000" + strconv.Itoa(i)
+		req.Header.Set("X-API-Key", "key-"+strconv.Itoa(i))
+		req.Header.Set("X-Cashier-ID", "cashier-"+strconv.Itoa(i))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		codes = append(codes, rec.Code)
+	}
+
+	if codes[0] != http.StatusOK || codes[1] != http.StatusOK || codes[2] != http.StatusTooManyRequests {
+		t.Errorf("statuses = %v, want [200 200 429] for one IP sending new headers each time", codes)
+	}
+}
+
+func TestRateLimitKeepsClientsApart(t *testing.T) {
+	limit, err := ratelimit.ParseLimit("1/1m")
+	if err != nil {
+		t.Fatalf("parse limit: %v", err)
+	}
+	h := rateLimit(ratelimit.NewMemory(), "GET /report/products", limit, clients{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for _, addr := range []string{"203.0.113.7:1234", "198.51.100.2:1234"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/report/products", nil)
+		req.RemoteAddr = addr
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Errorf("%s: status = %d, want 200", addr, rec.Code)
+		}
+	}
+}
+
+func TestClientKey(t *testing.T) {
+	proxies, err := ratelimit.ParseProxies([]string{"10.0.0.0/8", "192.0.2.1"})
+	if err != nil {
+		t.Fatalf("parse proxies: %v", err)
+	}
+	c := clients{
+		trustedProxies: proxies,
+		identity:       func(r *http.Request) string { return r.Header.Get("X-Test-Identity") },
+	}
+
+	tests := []struct {
+		name      string
+		remote    string
+		forwarded []string
+		identity  string
+		want      string
+	}{
+		{"direct client", "203.0.113.7:1234", nil, "", "ip:203.0.113.7"},
+		{"untrusted proxy is the client", "198.51.100.2:1234", []string{"203.0.113.7"}, "", "ip:198.51.100.2"},
+		{"trusted proxy", "10.1.2.3:1234", []string{"203.0.113.7"}, "", "ip:203.0.113.7"},
+		{"chain of trusted proxies", "10.1.2.3:1234", []string{"203.0.113.7, 192.0.2.1", "10.9.9.9"}, "", "ip:203.0.113.7"},
+		{"client-written entries are ignored", "10.1.2.3:1234", []string{"198.51.100.9, 203.0.113.7"}, "", "ip:203.0.113.7"},
+		{"trusted proxy without header", "10.1.2.3:1234", nil, "", "ip:10.1.2.3"},
+		{"malformed entry stops the walk", "10.1.2.3:1234", []string{"nonsense, 10.9.9.9"}, "", "ip:10.9.9.9"},
+		{"identity wins", "10.1.2.3:1234", []string{"203.0.113.7"}, "terminal-4", "id:terminal-4"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/products", nil)
+			req.RemoteAddr = tt.remote
+			for _, v := range tt.forwarded {
+				req.Header.Add("X-Forwarded-For", v)
+			}
+			if tt.identity != "" {
+				req.Header.Set("X-Test-Identity", tt.identity)
+			}
+			if got := c.key(req); got != tt.want {
+				t.Errorf("key = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
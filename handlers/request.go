@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"kasir-api/apperror"
+)
+
+// decodeJSON strictly decodes the request body into v: unknown fields, a
+// second JSON value after the first and bodies over the size limit set by
+// middleware.BodyLimit are all rejected.
+func decodeJSON(r *http.Request, v any) error {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(v); err != nil {
+		return decodeError(err)
+	}
+	if err := dec.Decode(&struct{}{}); err != io.EOF {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return decodeError(err)
+		}
+		return apperror.BadRequest("request body must contain a single JSON value")
+	}
+	return nil
+}
+
+// includeDeletedParam reads ?include_deleted=true, which lists soft-deleted
+// records alongside active ones.
+func includeDeletedParam(r *http.Request) (bool, error) {
+	value := r.URL.Query().Get("include_deleted")
+	if value == "" {
+		return false, nil
+	}
+
+	include, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, apperror.Validation("include_deleted", "include_deleted must be true or false")
+	}
+	return include, nil
+}
+
+func decodeError(err error) error {
+	var maxBytesErr *http.MaxBytesError
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return apperror.New(apperror.CodePayloadTooLarge, "request body must not exceed %d bytes", maxBytesErr.Limit)
+	case errors.Is(err, io.EOF):
+		return apperror.BadRequest("request body is required")
+	case errors.As(err, &syntaxErr), errors.Is(err, io.ErrUnexpectedEOF):
+		return apperror.BadRequest("request body is not valid JSON")
+	case errors.As(err, &typeErr):
+		return apperror.Validation(typeErr.Field, fmt.Sprintf("%s must be %s", typeErr.Field, jsonTypeName(typeErr.Type)))
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		// encoding/json has no typed error for DisallowUnknownFields
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return apperror.Validation(field, "unknown field "+field)
+	default:
+		return apperror.BadRequest("invalid request payload")
+	}
+}
+
+// jsonTypeName describes t the way a JSON client would see it.
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	default:
+		return "an object"
+	}
+}
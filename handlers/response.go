@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"kasir-api/apperror"
+)
+
+// Problem is an RFC 7807 problem details body.
+type Problem struct {
+	Type     string                `json:"type"`
+	Title    string                `json:"title"`
+	Status   int                   `json:"status"`
+	Detail   string                `json:"detail,omitempty"`
+	Instance string                `json:"instance,omitempty"`
+	Code     apperror.Code         `json:"code"`
+	Errors   []apperror.FieldError `json:"errors,omitempty"`
+}
+
+var statusByCode = map[apperror.Code]int{
+	apperror.CodeBadRequest:        http.StatusBadRequest,
+	apperror.CodeMethodNotAllowed:  http.StatusMethodNotAllowed,
+	apperror.CodePayloadTooLarge:   http.StatusRequestEntityTooLarge,
+	apperror.CodeValidation:        http.StatusUnprocessableEntity,
+	apperror.CodeNotFound:          http.StatusNotFound,
+	apperror.CodeConflict:          http.StatusConflict,
+	apperror.CodeInsufficientStock: http.StatusConflict,
+	apperror.CodeRateLimited:       http.StatusTooManyRequests,
+	apperror.CodeInternal:          http.StatusInternalServerError,
+}
+
+// writeError renders err as application/problem+json. Errors that are not
+// *apperror.Error are logged and reported as a generic internal error so raw
+// database messages never reach the client.
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	problem := Problem{Code: apperror.CodeInternal, Detail: "internal server error"}
+
+	var appErr *apperror.Error
+	if errors.As(err, &appErr) {
+		problem.Code = appErr.Code
+		problem.Detail = appErr.Message
+		problem.Errors = appErr.Fields
+	}
+	if problem.Code == apperror.CodeInternal {
+		slog.ErrorContext(r.Context(), "request failed", slog.String("method", r.Method),
+			slog.String("path", r.URL.Path), slog.Any("error", err))
+	} else if problem.Code == apperror.CodeValidation || problem.Code == apperror.CodeBadRequest ||
+		problem.Code == apperror.CodePayloadTooLarge {
+		slog.DebugContext(r.Context(), "request rejected", slog.String("code", string(problem.Code)),
+			slog.String("detail", problem.Detail))
+	} else {
+		slog.InfoContext(r.Context(), "request refused", slog.String("code", string(problem.Code)),
+			slog.String("detail", problem.Detail))
+	}
+
+	problem.Status = statusByCode[problem.Code]
+	problem.Title = http.StatusText(problem.Status)
+	problem.Type = "/problems/" + string(problem.Code)
+	problem.Instance = r.URL.Path
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(problem.Status)
+	json.NewEncoder(w).Encode(problem)
+}
+
+func methodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	writeError(w, r, apperror.New(apperror.CodeMethodNotAllowed, "method %s not allowed", r.Method))
+}
+
+func notFound(w http.ResponseWriter, r *http.Request) {
+	writeError(w, r, apperror.NotFound("resource not found"))
+}
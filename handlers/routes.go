@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"kasir-api/middleware"
+	"kasir-api/models"
+	"kasir-api/openapi"
+	"kasir-api/ratelimit"
+)
+
+// Handlers groups everything the router dispatches to.
+type Handlers struct {
+	Health      *HealthHandler
+	Category    *CategoryHandler
+	Product     *ProductHandler
+	PriceRule   *PriceRuleHandler
+	Modifier    *ModifierHandler
+	Customer    *CustomerHandler
+	Voucher     *VoucherHandler
+	Receivable  *ReceivableHandler
+	Transaction *TransactionHandler
+	Shift       *ShiftHandler
+	Cart        *CartHandler
+	Audit       *AuditHandler
+	Metrics     http.Handler
+}
+
+// RouterOptions configures how API routes are mounted.
+type RouterOptions struct {
+	// Legacy wraps the unversioned /api aliases of the v1 routes.
+	Legacy middleware.Middleware
+
+	// Limiter enforces RateLimits on API routes when set. Limits are looked
+	// up by the unprefixed pattern, e.g. "GET /report/sales-summary", so a
+	// route and its legacy alias share one bucket per client.
+	Limiter    ratelimit.Limiter
+	RateLimits ratelimit.Policy
+
+	// TrustedProxies are the reverse proxies whose X-Forwarded-For names
+	// the client IP for rate limiting.
+	TrustedProxies []netip.Prefix
+
+	// Identity returns the authenticated caller of a request, or "" when it
+	// has none. Rate limits key by it before falling back to the client IP.
+	Identity func(*http.Request) string
+}
+
+// NewRouter registers every route in one place and wraps the result in
+// middlewares, the first one listed being the outermost.
+func NewRouter(h Handlers, opts RouterOptions, middlewares ...middleware.Middleware) http.Handler {
+	mux := http.NewServeMux()
+	spec := openapi.New(openapi.Info{Title: "Kasir API", Description: apiDescription, Version: "1.0"})
+	spec.Problem = Problem{}
+
+	root := group{mux: mux, spec: spec}
+	root.handleFunc("GET /livez", h.Health.HandleLive, openapi.Route{
+		Summary:     "Liveness",
+		Description: "The process is up. Does not check dependencies.",
+		Tag:         "Health",
+		Response:    models.HealthCheck{},
+	})
+	root.handleFunc("GET /readyz", h.Health.HandleReady, openapi.Route{
+		Summary: "Readiness",
+		Description: "Pings the database and checks the schema is at the expected migration version. " +
+			"Also reports pool usage, build version and uptime, with status 503 when not ready. `/health` is an alias.",
+		Tag:      "Health",
+		Response: models.ReadinessReport{},
+	})
+	mux.HandleFunc("GET /health", h.Health.HandleReady) // kept for existing monitors
+	root.handle("GET /metrics", h.Metrics, openapi.Route{
+		Summary: "Prometheus metrics",
+		Description: "Prometheus text exposition: `kasir_http_requests_total` and `kasir_http_request_duration_seconds` " +
+			"per route/method/status, `go_sql_*` pool stats, `kasir_checkouts_total` per outcome and " +
+			"`kasir_revenue_ingested_rupiah_total`.",
+		Tag:         "Health",
+		Response:    "",
+		ContentType: "text/plain",
+	})
+
+	api := group{mux: mux, limiter: opts.Limiter, limits: opts.RateLimits,
+		clients: clients{trustedProxies: opts.TrustedProxies, identity: opts.Identity}}
+
+	v1 := api
+	v1.prefix, v1.spec = "/api/v1", spec
+	registerV1(v1, h)
+
+	// pre-versioning paths serve v1 unchanged until their sunset date
+	legacy := api
+	legacy.prefix, legacy.wrap = "/api", opts.Legacy
+	registerV1(legacy, h)
+
+	mux.Handle("GET /openapi.json", newSpecHandler(spec))
+	mux.HandleFunc("GET /docs", serveSwaggerUI)
+
+	return middleware.Chain(&router{mux: mux}, middlewares...)
+}
+
+const apiDescription = `Simple POS (Point of Sale) API for managing products, categories, and transactions.
+
+Errors are returned as application/problem+json (see the Problem schema) with a machine-readable ` + "`code`" + `:
+400 bad_request, 404 not_found, 405 method_not_allowed, 409 conflict / insufficient_stock,
+413 payload_too_large, 422 validation, 429 rate_limited, 500 internal. JSON bodies are decoded strictly: unknown fields are a
+validation error and anything after the first JSON value is rejected. A 405 response lists the methods the path does support in its ` + "`Allow`" + ` header.
+Every response carries an ` + "`X-Request-ID`" + ` header; send your own to correlate logs.
+
+All endpoints are versioned under ` + "`/api/v1`" + `. The unversioned ` + "`/api/...`" + ` paths still serve v1 but are
+deprecated: their responses carry ` + "`Deprecation`, `Sunset`" + ` and a ` + "`Link`" + ` header with rel="successor-version".
+
+API routes are rate limited per client and route. A client is its authenticated identity where the deployment
+has one; this API has no authentication of its own, so otherwise it is the client IP. Behind a reverse proxy the IP
+is taken from ` + "`X-Forwarded-For`" + ` only when the proxy is configured as trusted. Terminals sharing one public IP, e.g.
+behind the shop's NAT, share one bucket. Responses carry
+` + "`RateLimit-Policy`, `RateLimit-Limit`, `RateLimit-Remaining` and `RateLimit-Reset`" + `; a 429 also carries ` + "`Retry-After`" + `.`
+
+// group registers routes under a path prefix, so each API version can mount
+// the same or different handlers side by side. Routes of a group with a spec
+// are documented in it.
+type group struct {
+	mux     *http.ServeMux
+	prefix  string
+	wrap    middleware.Middleware
+	spec    *openapi.Document
+	limiter ratelimit.Limiter
+	limits  ratelimit.Policy
+	clients clients
+}
+
+// handle registers h for a "METHOD /path" pattern relative to the prefix.
+func (g group) handle(pattern string, h http.Handler, route openapi.Route) {
+	method, path, _ := strings.Cut(pattern, " ")
+	limited := g.limiter != nil && !g.limits.For(pattern).Unlimited()
+	if limited {
+		h = rateLimit(g.limiter, pattern, g.limits.For(pattern), g.clients)(h)
+	}
+	if g.wrap != nil {
+		h = g.wrap(h)
+	}
+	g.mux.Handle(method+" "+g.prefix+path, h)
+	if g.spec != nil {
+		if route.Body != nil {
+			route.Errors = append(route.Errors, http.StatusRequestEntityTooLarge)
+		}
+		if limited {
+			route.Errors = append(route.Errors, http.StatusTooManyRequests)
+		}
+		g.spec.Add(method, g.prefix+path, route)
+	}
+}
+
+func (g group) handleFunc(pattern string, fn http.HandlerFunc, route openapi.Route) {
+	g.handle(pattern, fn, route)
+}
+
+// router answers unmatched requests with problem+json instead of the
+// ServeMux's plain-text 404/405, keeping the Allow header the mux computed.
+type router struct {
+	mux *http.ServeMux
+}
+
+func (rt *router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if _, pattern := rt.mux.Handler(r); pattern != "" {
+		rt.mux.ServeHTTP(w, r)
+		return
+	}
+
+	probe := &probeWriter{header: http.Header{}}
+	rt.mux.ServeHTTP(probe, r)
+
+	switch probe.status {
+	case http.StatusMethodNotAllowed:
+		w.Header().Set("Allow", probe.header.Get("Allow"))
+		methodNotAllowed(w, r)
+	case http.StatusNotFound:
+		notFound(w, r)
+	default:
+		// redirects (e.g. path cleaning) go through untouched
+		for key, values := range probe.header {
+			w.Header()[key] = values
+		}
+		w.WriteHeader(probe.status)
+	}
+}
+
+// probeWriter captures what the ServeMux would have answered without writing it.
+type probeWriter struct {
+	header http.Header
+	status int
+}
+
+func (p *probeWriter) Header() http.Header { return p.header }
+
+func (p *probeWriter) Write(b []byte) (int, error) {
+	if p.status == 0 {
+		p.status = http.StatusOK
+	}
+	return len(b), nil
+}
+
+func (p *probeWriter) WriteHeader(status int) { p.status = status }
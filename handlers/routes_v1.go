@@ -0,0 +1,651 @@
+package handlers
+
+import (
+	"net/http"
+
+	"kasir-api/models"
+	"kasir-api/openapi"
+)
+
+var (
+	cashierHeader  = openapi.Header("X-Cashier-ID", "Cashier acting on the request")
+	terminalHeader = openapi.Header("X-Terminal-ID", "Terminal or register the request comes from")
+	storeHeader    = openapi.Header("X-Store-ID", "Store the terminal belongs to")
+	startDateQuery = openapi.Query("start_date", "Start date (YYYY-MM-DD); today when start or end is missing")
+	endDateQuery   = openapi.Query("end_date", "End date (YYYY-MM-DD), inclusive")
+	storeQuery     = openapi.Query("store_id", "Only count transactions of this store")
+
+	includeDeletedQuery = openapi.Query("include_deleted", "true to include soft-deleted records")
+
+	attribution = []openapi.Parameter{cashierHeader, terminalHeader, storeHeader}
+)
+
+// registerV1 mounts the v1 API. A later version gets its own registerVn and
+// handler types on top of the same services, so versions can coexist.
+func registerV1(g group, h Handlers) {
+	// Categories
+	g.handleFunc("GET /categories", h.Category.GetAll, openapi.Route{
+		Summary:  "Get all categories",
+		Tag:      "Categories",
+		Params:   []openapi.Parameter{openapi.Query("name", "Filter categories by name (partial match)"), includeDeletedQuery},
+		Response: []models.Category{},
+	})
+	g.handleFunc("POST /categories", h.Category.Create, openapi.Route{
+		Summary:  "Create category",
+		Tag:      "Categories",
+		Params:   []openapi.Parameter{cashierHeader},
+		Body:     models.Category{},
+		Status:   http.StatusCreated,
+		Response: models.Category{},
+		Errors:   []int{http.StatusBadRequest, http.StatusConflict, http.StatusUnprocessableEntity},
+	})
+	g.handleFunc("GET /categories/{id}", h.Category.GetByID, openapi.Route{
+		Summary:  "Get category by ID",
+		Tag:      "Categories",
+		Params:   []openapi.Parameter{includeDeletedQuery},
+		Response: models.Category{},
+		Errors:   []int{http.StatusBadRequest, http.StatusNotFound},
+	})
+	g.handleFunc("PUT /categories/{id}", h.Category.Update, openapi.Route{
+		Summary:     "Update category",
+		Description: "Fields left empty keep their current value.",
+		Tag:         "Categories",
+		Params:      []openapi.Parameter{cashierHeader},
+		Body:        models.Category{},
+		Response:    models.Category{},
+		Errors:      []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusUnprocessableEntity},
+	})
+	g.handleFunc("DELETE /categories/{id}", h.Category.Delete, openapi.Route{
+		Summary:     "Delete category",
+		Description: "Soft delete: the category is hidden from listings and can be restored. Refused while active products use it.",
+		Tag:         "Categories",
+		Params:      []openapi.Parameter{cashierHeader},
+		Status:      http.StatusNoContent,
+		Errors:      []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict},
+	})
+	g.handleFunc("POST /categories/{id}/restore", h.Category.Restore, openapi.Route{
+		Summary:  "Restore category",
+		Tag:      "Categories",
+		Params:   []openapi.Parameter{cashierHeader},
+		Response: models.Category{},
+		Errors:   []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict},
+	})
+
+	// Products
+	g.handleFunc("GET /products", h.Product.GetAll, openapi.Route{
+		Summary:  "Get all products",
+		Tag:      "Products",
+		Params:   []openapi.Parameter{openapi.Query("name", "Filter products by name (partial match)"), includeDeletedQuery},
+		Response: []models.Product{},
+	})
+	g.handleFunc("POST /products", h.Product.Create, openapi.Route{
+		Summary:  "Create product",
+		Tag:      "Products",
+		Params:   []openapi.Parameter{cashierHeader},
+		Body:     models.Product{},
+		Status:   http.StatusCreated,
+		Response: models.Product{},
+		Errors:   []int{http.StatusBadRequest, http.StatusConflict, http.StatusUnprocessableEntity},
+	})
+	g.handleFunc("GET /products/{id}", h.Product.GetByID, openapi.Route{
+		Summary:  "Get product by ID",
+		Tag:      "Products",
+		Params:   []openapi.Parameter{includeDeletedQuery},
+		Response: models.Product{},
+		Errors:   []int{http.StatusBadRequest, http.StatusNotFound},
+	})
+	g.handleFunc("PUT /products/{id}", h.Product.Update, openapi.Route{
+		Summary:     "Update product",
+		Description: "Fields left empty or zero keep their current value. A new price takes effect immediately and is added to the price history. A bundle's stock comes from its components and cannot be set.",
+		Tag:         "Products",
+		Params:      []openapi.Parameter{cashierHeader},
+		Body:        models.Product{},
+		Response:    models.Product{},
+		Errors:      []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusUnprocessableEntity},
+	})
+	g.handleFunc("DELETE /products/{id}", h.Product.Delete, openapi.Route{
+		Summary:     "Delete product",
+		Description: "Soft delete: the product is hidden from listings and can no longer be sold, but past transactions keep it. It can be restored.",
+		Tag:         "Products",
+		Params:      []openapi.Parameter{cashierHeader},
+		Status:      http.StatusNoContent,
+		Errors:      []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict},
+	})
+	g.handleFunc("POST /products/{id}/restore", h.Product.Restore, openapi.Route{
+		Summary:  "Restore product",
+		Tag:      "Products",
+		Params:   []openapi.Parameter{cashierHeader},
+		Response: models.Product{},
+		Errors:   []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict},
+	})
+
+	g.handleFunc("GET /products/{id}/prices", h.Product.Prices, openapi.Route{
+		Summary:     "Price history",
+		Description: "Prices the product had, the price in effect and scheduled price changes.",
+		Tag:         "Products",
+		Response:    models.PriceHistory{},
+		Errors:      []int{http.StatusBadRequest, http.StatusNotFound},
+	})
+	g.handleFunc("POST /products/{id}/prices", h.Product.SchedulePrice, openapi.Route{
+		Summary:     "Schedule price change",
+		Description: "Set a price that takes effect at effective_at, e.g. new prices from Monday 00:00. Checkout uses the price in effect at sale time.",
+		Tag:         "Products",
+		Params:      []openapi.Parameter{cashierHeader},
+		Body:        models.SchedulePriceRequest{},
+		Status:      http.StatusCreated,
+		Response:    models.ProductPrice{},
+		Errors:      []int{http.StatusBadRequest, http.StatusNotFound, http.StatusUnprocessableEntity},
+	})
+	g.handleFunc("DELETE /products/{id}/prices/{priceID}", h.Product.CancelPrice, openapi.Route{
+		Summary:     "Cancel scheduled price",
+		Description: "Only prices that have not taken effect yet can be cancelled.",
+		Tag:         "Products",
+		Params:      []openapi.Parameter{cashierHeader},
+		Status:      http.StatusNoContent,
+		Errors:      []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict},
+	})
+
+	g.handleFunc("GET /products/{id}/bundle", h.Product.Bundle, openapi.Route{
+		Summary:     "Get bundle",
+		Description: "Components and choice groups of a bundle product, and how many bundles current stock can make up.",
+		Tag:         "Products",
+		Response:    models.Bundle{},
+		Errors:      []int{http.StatusBadRequest, http.StatusNotFound},
+	})
+	g.handleFunc("PUT /products/{id}/bundle", h.Product.SetBundle, openapi.Route{
+		Summary: "Set bundle",
+		Description: "Make the product a bundle (combo) of component products, optionally with choice groups such as any drink from a category. " +
+			"Selling it deducts the components' stock; its own stock is derived from them. A component of another bundle cannot become a bundle.",
+		Tag:      "Products",
+		Params:   []openapi.Parameter{cashierHeader},
+		Body:     models.SetBundleRequest{},
+		Response: models.Bundle{},
+		Errors:   []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusUnprocessableEntity},
+	})
+	g.handleFunc("DELETE /products/{id}/bundle", h.Product.RemoveBundle, openapi.Route{
+		Summary:     "Remove bundle",
+		Description: "Turn a bundle back into a regular product with its own stock.",
+		Tag:         "Products",
+		Params:      []openapi.Parameter{cashierHeader},
+		Status:      http.StatusNoContent,
+		Errors:      []int{http.StatusBadRequest, http.StatusNotFound},
+	})
+
+	// Modifiers
+	g.handleFunc("GET /modifier-groups", h.Modifier.GetAll, openapi.Route{
+		Summary:  "Get all modifier groups",
+		Tag:      "Modifiers",
+		Params:   []openapi.Parameter{includeDeletedQuery},
+		Response: []models.ModifierGroup{},
+	})
+	g.handleFunc("POST /modifier-groups", h.Modifier.Create, openapi.Route{
+		Summary: "Create modifier group",
+		Description: "Options such as extra shot or oat milk on a product or on every product of a category, " +
+			"with how many a line must and may pick. Price deltas are added to the unit price at checkout.",
+		Tag:      "Modifiers",
+		Params:   []openapi.Parameter{cashierHeader},
+		Body:     models.ModifierGroup{},
+		Status:   http.StatusCreated,
+		Response: models.ModifierGroup{},
+		Errors:   []int{http.StatusBadRequest, http.StatusConflict, http.StatusUnprocessableEntity},
+	})
+	g.handleFunc("GET /modifier-groups/{id}", h.Modifier.GetByID, openapi.Route{
+		Summary:  "Get modifier group by ID",
+		Tag:      "Modifiers",
+		Params:   []openapi.Parameter{includeDeletedQuery},
+		Response: models.ModifierGroup{},
+		Errors:   []int{http.StatusBadRequest, http.StatusNotFound},
+	})
+	g.handleFunc("PUT /modifier-groups/{id}", h.Modifier.Update, openapi.Route{
+		Summary:     "Update modifier group",
+		Description: "Replaces the group and its options; options get new IDs.",
+		Tag:         "Modifiers",
+		Params:      []openapi.Parameter{cashierHeader},
+		Body:        models.ModifierGroup{},
+		Response:    models.ModifierGroup{},
+		Errors:      []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusUnprocessableEntity},
+	})
+	g.handleFunc("DELETE /modifier-groups/{id}", h.Modifier.Delete, openapi.Route{
+		Summary:     "Delete modifier group",
+		Description: "Soft delete: the group is no longer offered at checkout.",
+		Tag:         "Modifiers",
+		Params:      []openapi.Parameter{cashierHeader},
+		Status:      http.StatusNoContent,
+		Errors:      []int{http.StatusBadRequest, http.StatusNotFound},
+	})
+	g.handleFunc("GET /products/{id}/modifier-groups", h.Modifier.ForProduct, openapi.Route{
+		Summary:     "Modifiers of a product",
+		Description: "Modifier groups a checkout line of the product can use: its own and those of its category.",
+		Tag:         "Modifiers",
+		Response:    []models.ModifierGroup{},
+		Errors:      []int{http.StatusBadRequest, http.StatusNotFound},
+	})
+
+	// Price rules
+	g.handleFunc("GET /price-rules", h.PriceRule.GetAll, openapi.Route{
+		Summary:  "Get all price rules",
+		Tag:      "Price rules",
+		Params:   []openapi.Parameter{includeDeletedQuery},
+		Response: []models.PriceRule{},
+	})
+	g.handleFunc("POST /price-rules", h.PriceRule.Create, openapi.Route{
+		Summary: "Create price rule",
+		Description: "Time-based pricing, e.g. happy hour on a category from 15:00 to 17:00 on weekdays. " +
+			"Times are store local time; at checkout the rule giving the lowest price applies and is recorded on the line.",
+		Tag:      "Price rules",
+		Params:   []openapi.Parameter{cashierHeader},
+		Body:     models.PriceRule{},
+		Status:   http.StatusCreated,
+		Response: models.PriceRule{},
+		Errors:   []int{http.StatusBadRequest, http.StatusConflict, http.StatusUnprocessableEntity},
+	})
+	g.handleFunc("GET /price-rules/{id}", h.PriceRule.GetByID, openapi.Route{
+		Summary:  "Get price rule by ID",
+		Tag:      "Price rules",
+		Params:   []openapi.Parameter{includeDeletedQuery},
+		Response: models.PriceRule{},
+		Errors:   []int{http.StatusBadRequest, http.StatusNotFound},
+	})
+	g.handleFunc("PUT /price-rules/{id}", h.PriceRule.Update, openapi.Route{
+		Summary:     "Update price rule",
+		Description: "Replaces every field of the rule.",
+		Tag:         "Price rules",
+		Params:      []openapi.Parameter{cashierHeader},
+		Body:        models.PriceRule{},
+		Response:    models.PriceRule{},
+		Errors:      []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusUnprocessableEntity},
+	})
+	g.handleFunc("DELETE /price-rules/{id}", h.PriceRule.Delete, openapi.Route{
+		Summary:     "Delete price rule",
+		Description: "Soft delete: the rule stops applying; lines it priced keep referring to it.",
+		Tag:         "Price rules",
+		Params:      []openapi.Parameter{cashierHeader},
+		Status:      http.StatusNoContent,
+		Errors:      []int{http.StatusBadRequest, http.StatusNotFound},
+	})
+
+	// Customers
+	g.handleFunc("GET /customers", h.Customer.GetAll, openapi.Route{
+		Summary:  "Get all customers",
+		Tag:      "Customers",
+		Params:   []openapi.Parameter{openapi.Query("q", "Filter by name, phone, email or member code (partial match)"), includeDeletedQuery},
+		Response: []models.Customer{},
+	})
+	g.handleFunc("POST /customers", h.Customer.Create, openapi.Route{
+		Summary:     "Create customer",
+		Description: "A member code is generated when member_code is empty. points and receivable are read-only; credit_limit allows kasbon sales up to that amount.",
+		Tag:         "Customers",
+		Params:      []openapi.Parameter{cashierHeader},
+		Body:        models.Customer{},
+		Status:      http.StatusCreated,
+		Response:    models.Customer{},
+		Errors:      []int{http.StatusBadRequest, http.StatusConflict, http.StatusUnprocessableEntity},
+	})
+	g.handleFunc("GET /customers/{id}", h.Customer.GetByID, openapi.Route{
+		Summary:  "Get customer by ID",
+		Tag:      "Customers",
+		Params:   []openapi.Parameter{includeDeletedQuery},
+		Response: models.Customer{},
+		Errors:   []int{http.StatusBadRequest, http.StatusNotFound},
+	})
+	g.handleFunc("PUT /customers/{id}", h.Customer.Update, openapi.Route{
+		Summary:     "Update customer",
+		Description: "Fields left empty keep their current value. credit_limit is changed with PUT /customers/{id}/credit-limit.",
+		Tag:         "Customers",
+		Params:      []openapi.Parameter{cashierHeader},
+		Body:        models.Customer{},
+		Response:    models.Customer{},
+		Errors:      []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusUnprocessableEntity},
+	})
+	g.handleFunc("DELETE /customers/{id}", h.Customer.Delete, openapi.Route{
+		Summary:     "Delete customer",
+		Description: "Soft delete: past sales and points are kept, but the customer can no longer be attached to a checkout.",
+		Tag:         "Customers",
+		Params:      []openapi.Parameter{cashierHeader},
+		Status:      http.StatusNoContent,
+		Errors:      []int{http.StatusBadRequest, http.StatusNotFound},
+	})
+	g.handleFunc("GET /customers/{id}/points", h.Customer.Points, openapi.Route{
+		Summary: "Loyalty points",
+		Description: "Points balance and ledger, newest first: points earned on sales, redeemed at checkout " +
+			"and reversed by voids and refunds.",
+		Tag:      "Customers",
+		Response: models.LoyaltyAccount{},
+		Errors:   []int{http.StatusBadRequest, http.StatusNotFound},
+	})
+	g.handleFunc("GET /customers/{id}/transactions", h.Customer.Purchases, openapi.Route{
+		Summary:     "Purchase history",
+		Description: "Transactions of the customer, newest first, including voided and refunded ones.",
+		Tag:         "Customers",
+		Params:      []openapi.Parameter{openapi.Query("limit", "Maximum number of transactions, 50 by default and at most 500")},
+		Response:    []models.CustomerPurchase{},
+		Errors:      []int{http.StatusBadRequest, http.StatusNotFound, http.StatusUnprocessableEntity},
+	})
+	g.handleFunc("GET /customers/{id}/receivables", h.Receivable.Account, openapi.Route{
+		Summary: "Receivables",
+		Description: "What the customer owes on kasbon, the credit left and the ledger, oldest first: kasbon sales " +
+			"and repayments with the balance after each. Voided and refunded sales drop out.",
+		Tag:      "Customers",
+		Response: models.ReceivableAccount{},
+		Errors:   []int{http.StatusBadRequest, http.StatusNotFound},
+	})
+	g.handleFunc("POST /customers/{id}/receivables/payments", h.Receivable.Repay, openapi.Route{
+		Summary: "Repay kasbon",
+		Description: "Record a full or partial repayment, at most what the customer owes. payment_method defaults to cash; " +
+			"the payment counts towards the open shift of the terminal in the headers.",
+		Tag:      "Customers",
+		Params:   attribution,
+		Body:     models.RepaymentRequest{},
+		Status:   http.StatusCreated,
+		Response: models.ReceivablePayment{},
+		Errors:   []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusUnprocessableEntity},
+	})
+	g.handleFunc("PUT /customers/{id}/credit-limit", h.Receivable.SetCreditLimit, openapi.Route{
+		Summary:     "Set credit limit",
+		Description: "The most the customer may owe on kasbon; 0 turns kasbon off. A limit below the current balance only stops further kasbon sales.",
+		Tag:         "Customers",
+		Params:      []openapi.Parameter{cashierHeader},
+		Body:        models.CreditLimitRequest{},
+		Response:    models.Customer{},
+		Errors:      []int{http.StatusBadRequest, http.StatusNotFound, http.StatusUnprocessableEntity},
+	})
+
+	// Vouchers
+	g.handleFunc("GET /vouchers", h.Voucher.GetAll, openapi.Route{
+		Summary:  "Get all vouchers",
+		Tag:      "Vouchers",
+		Params:   []openapi.Parameter{includeDeletedQuery},
+		Response: []models.Voucher{},
+	})
+	g.handleFunc("POST /vouchers", h.Voucher.Create, openapi.Route{
+		Summary: "Create voucher",
+		Description: "A code taking percent_off or amount_off the checkout total. max_uses 1 makes it single-use and no max_uses unlimited; " +
+			"per_customer_limit needs the checkout to name a customer. Codes are case-insensitive; an 8-character code is generated when code is empty.",
+		Tag:      "Vouchers",
+		Params:   []openapi.Parameter{cashierHeader},
+		Body:     models.Voucher{},
+		Status:   http.StatusCreated,
+		Response: models.Voucher{},
+		Errors:   []int{http.StatusBadRequest, http.StatusConflict, http.StatusUnprocessableEntity},
+	})
+	g.handleFunc("GET /vouchers/{id}", h.Voucher.GetByID, openapi.Route{
+		Summary:  "Get voucher by ID",
+		Tag:      "Vouchers",
+		Params:   []openapi.Parameter{includeDeletedQuery},
+		Response: models.Voucher{},
+		Errors:   []int{http.StatusBadRequest, http.StatusNotFound},
+	})
+	g.handleFunc("PUT /vouchers/{id}", h.Voucher.Update, openapi.Route{
+		Summary:     "Update voucher",
+		Description: "Replaces every field of the voucher; uses so far are kept.",
+		Tag:         "Vouchers",
+		Params:      []openapi.Parameter{cashierHeader},
+		Body:        models.Voucher{},
+		Response:    models.Voucher{},
+		Errors:      []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusUnprocessableEntity},
+	})
+	g.handleFunc("DELETE /vouchers/{id}", h.Voucher.Delete, openapi.Route{
+		Summary:     "Delete voucher",
+		Description: "Soft delete: the code can no longer be redeemed.",
+		Tag:         "Vouchers",
+		Params:      []openapi.Parameter{cashierHeader},
+		Status:      http.StatusNoContent,
+		Errors:      []int{http.StatusBadRequest, http.StatusNotFound},
+	})
+	g.handleFunc("GET /vouchers/{id}/redemptions", h.Voucher.Redemptions, openapi.Route{
+		Summary:     "Voucher redemptions",
+		Description: "Uses of the voucher, newest first. Uses by voided or refunded sales are given back and show reversed_at.",
+		Tag:         "Vouchers",
+		Response:    []models.VoucherRedemption{},
+		Errors:      []int{http.StatusBadRequest, http.StatusNotFound},
+	})
+
+	// Shifts
+	g.handleFunc("POST /shifts", h.Shift.Open, openapi.Route{
+		Summary:     "Open shift",
+		Description: "Open a cash drawer shift for the cashier and terminal in the headers.",
+		Tag:         "Shifts",
+		Params:      attribution,
+		Body:        models.OpenShiftRequest{},
+		Status:      http.StatusCreated,
+		Response:    models.Shift{},
+		Errors:      []int{http.StatusBadRequest, http.StatusConflict, http.StatusUnprocessableEntity},
+	})
+	g.handleFunc("GET /shifts/current", h.Shift.GetCurrent, openapi.Route{
+		Summary:     "Current shift",
+		Description: "Get the open shift of the terminal in the headers.",
+		Tag:         "Shifts",
+		Params:      []openapi.Parameter{terminalHeader},
+		Response:    models.Shift{},
+		Errors:      []int{http.StatusNotFound, http.StatusUnprocessableEntity},
+	})
+	g.handleFunc("GET /shifts/{id}", h.Shift.GetByID, openapi.Route{
+		Summary:  "Get shift",
+		Tag:      "Shifts",
+		Response: models.Shift{},
+		Errors:   []int{http.StatusBadRequest, http.StatusNotFound},
+	})
+	g.handleFunc("GET /shifts/{id}/report", h.Shift.Report, openapi.Route{
+		Summary:     "Shift report",
+		Description: "X report (running totals) of an open shift, or Z report of a closed one.",
+		Tag:         "Shifts",
+		Response:    models.ShiftReport{},
+		Errors:      []int{http.StatusBadRequest, http.StatusNotFound},
+	})
+	g.handleFunc("POST /shifts/{id}/cash-movements", h.Shift.AddCashMovement, openapi.Route{
+		Summary:     "Pay-in / pay-out",
+		Description: "Record cash put into or taken out of the drawer during an open shift.",
+		Tag:         "Shifts",
+		Params:      []openapi.Parameter{cashierHeader},
+		Body:        models.CashMovement{},
+		Status:      http.StatusCreated,
+		Response:    models.CashMovement{},
+		Errors:      []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusUnprocessableEntity},
+	})
+	g.handleFunc("POST /shifts/{id}/close", h.Shift.Close, openapi.Route{
+		Summary:     "Close shift",
+		Description: "Close the shift with counted amounts per payment method and get the Z report.",
+		Tag:         "Shifts",
+		Params:      []openapi.Parameter{cashierHeader},
+		Body:        models.CloseShiftRequest{},
+		Response:    models.ShiftReport{},
+		Errors:      []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusUnprocessableEntity},
+	})
+
+	// Transactions
+	g.handleFunc("POST /checkout", h.Transaction.Checkout, openapi.Route{
+		Summary: "Checkout",
+		Description: "Process a new transaction with multiple items. With customer_id the customer earns points on the amount paid; " +
+			"redeem_points spends points of that customer as a discount on the total. voucher_code takes its discount off the total first, " +
+			"and its use is taken in the same database transaction as the sale. payment_method kasbon puts the total on the customer's account " +
+			"and is refused past their credit limit.",
+		Tag:      "Transactions",
+		Params:   attribution,
+		Body:     models.CheckoutRequest{},
+		Response: models.Transaction{},
+		Errors:   []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusUnprocessableEntity},
+	})
+	g.handleFunc("GET /transactions/{id}", h.Transaction.GetByID, openapi.Route{
+		Summary:     "Get transaction",
+		Description: "Receipt of a transaction. Lines show the product name, SKU, unit price, discount and cost as they were at checkout.",
+		Tag:         "Transactions",
+		Response:    models.Transaction{},
+		Errors:      []int{http.StatusBadRequest, http.StatusNotFound},
+	})
+	g.handleFunc("GET /transactions/{id}/receipt", h.Transaction.Receipt, openapi.Route{
+		Summary:     "Print receipt",
+		Description: "The receipt as plain text for a 58 mm printer, with modifiers, notes and bundle contents under each line.",
+		Tag:         "Transactions",
+		Response:    "",
+		ContentType: "text/plain",
+		Errors:      []int{http.StatusBadRequest, http.StatusNotFound},
+	})
+	g.handleFunc("POST /transactions/{id}/void", h.Transaction.Void, openapi.Route{
+		Summary: "Void transaction",
+		Description: "Cancel a completed transaction, return its items to stock, reverse its loyalty points and give back its voucher use. " +
+			"The money counts towards the open shift of the terminal in the headers; with open shifts required, a terminal without one is refused.",
+		Tag:          "Transactions",
+		Params:       attribution,
+		Body:         models.StatusChangeRequest{},
+		OptionalBody: true,
+		Response:     models.Transaction{},
+		Errors:       []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusUnprocessableEntity},
+	})
+	g.handleFunc("POST /transactions/{id}/refund", h.Transaction.Refund, openapi.Route{
+		Summary: "Refund transaction",
+		Description: "Refund a completed transaction, return its items to stock, reverse its loyalty points and give back its voucher use. " +
+			"The money counts towards the open shift of the terminal in the headers; with open shifts required, a terminal without one is refused.",
+		Tag:          "Transactions",
+		Params:       attribution,
+		Body:         models.StatusChangeRequest{},
+		OptionalBody: true,
+		Response:     models.Transaction{},
+		Errors:       []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusUnprocessableEntity},
+	})
+
+	// Reports
+	g.handleFunc("GET /report/sales-summary", h.Transaction.SalesSummary, openapi.Route{
+		Summary:     "Sales summary",
+		Description: "Revenue, total transactions and best seller of completed sales.",
+		Tag:         "Reports",
+		Params:      []openapi.Parameter{startDateQuery, endDateQuery},
+		Response:    models.SalesSummary{},
+		Errors:      []int{http.StatusUnprocessableEntity},
+	})
+	g.handleFunc("GET /report/products", h.Transaction.ProductReport, openapi.Route{
+		Summary:     "Sales per product",
+		Description: "Quantity and revenue per product of completed sales. Bundles count towards their components, with the bundle revenue allocated by component price.",
+		Tag:         "Reports",
+		Params:      []openapi.Parameter{startDateQuery, endDateQuery},
+		Response:    []models.ProductSales{},
+		Errors:      []int{http.StatusUnprocessableEntity},
+	})
+	g.handleFunc("GET /report/cashiers", h.Transaction.CashierReport, openapi.Route{
+		Summary: "Sales per cashier",
+		Description: "Sales count, revenue, average basket, voids and refunds per cashier. Sales count for the cashier who rang them up, " +
+			"even if later voided or refunded; voids and refunds count for the cashier who made them, in the period they were made.",
+		Tag:      "Reports",
+		Params:   []openapi.Parameter{startDateQuery, endDateQuery, storeQuery},
+		Response: []models.SalesBreakdown{},
+		Errors:   []int{http.StatusUnprocessableEntity},
+	})
+	g.handleFunc("GET /report/terminals", h.Transaction.TerminalReport, openapi.Route{
+		Summary: "Sales per terminal",
+		Description: "Sales count, revenue, average basket, voids and refunds per terminal. Sales count for the terminal they were rung up on, " +
+			"even if later voided or refunded; voids and refunds count for the terminal they were made on, in the period they were made.",
+		Tag:      "Reports",
+		Params:   []openapi.Parameter{startDateQuery, endDateQuery, storeQuery},
+		Response: []models.SalesBreakdown{},
+		Errors:   []int{http.StatusUnprocessableEntity},
+	})
+	g.handleFunc("GET /report/receivables-aging", h.Receivable.Aging, openapi.Route{
+		Summary: "Receivables aging",
+		Description: "What each customer owes on kasbon, split by the age of the unpaid sales: 0-30, 31-60 and over 60 days. " +
+			"Repayments settle the oldest sales first.",
+		Tag:      "Reports",
+		Params:   []openapi.Parameter{openapi.Query("as_of", "Date to age on (YYYY-MM-DD); today when missing")},
+		Response: models.AgingReport{},
+		Errors:   []int{http.StatusUnprocessableEntity},
+	})
+
+	// Carts
+	g.handleFunc("GET /carts", h.Cart.GetAll, openapi.Route{
+		Summary:     "List carts",
+		Description: "List carts, e.g. held carts of a terminal to resume.",
+		Tag:         "Carts",
+		Params: []openapi.Parameter{
+			openapi.Query("status", "active, held, converted or cancelled"),
+			openapi.Query("terminal_id", "Only carts of this terminal"),
+		},
+		Response: []models.Cart{},
+		Errors:   []int{http.StatusUnprocessableEntity},
+	})
+	g.handleFunc("POST /carts", h.Cart.Create, openapi.Route{
+		Summary: "Create cart",
+		Description: "With reserve_stock the cart's lines hold their stock back from other carts and checkouts " +
+			"until the reservation expires; every change to the cart extends it.",
+		Tag:          "Carts",
+		Params:       attribution,
+		Body:         models.CreateCartRequest{},
+		OptionalBody: true,
+		Status:       http.StatusCreated,
+		Response:     models.Cart{},
+		Errors:       []int{http.StatusBadRequest, http.StatusUnprocessableEntity},
+	})
+	g.handleFunc("GET /carts/{id}", h.Cart.GetByID, openapi.Route{
+		Summary: "Get cart",
+		Description: "Get the cart with lines priced as checkout would price them now, price rules included. Lines whose " +
+			"product was deleted or is short of stock are flagged in unavailable and left out of the total.",
+		Tag:      "Carts",
+		Response: models.Cart{},
+		Errors:   []int{http.StatusBadRequest, http.StatusNotFound},
+	})
+	g.handleFunc("DELETE /carts/{id}", h.Cart.Cancel, openapi.Route{
+		Summary: "Cancel cart",
+		Tag:     "Carts",
+		Status:  http.StatusNoContent,
+		Errors:  []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict},
+	})
+	g.handleFunc("POST /carts/{id}/items", h.Cart.AddItem, openapi.Route{
+		Summary: "Add cart line",
+		Description: "Add quantity of a product to the cart. Bundles with choice groups and products with a required modifier group " +
+			"cannot be added, as cart lines hold no picks; sell them through POST /checkout.",
+		Tag:      "Carts",
+		Body:     models.CartItemRequest{},
+		Response: models.Cart{},
+		Errors:   []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusUnprocessableEntity},
+	})
+	g.handleFunc("PUT /carts/{id}/items/{product_id}", h.Cart.UpdateItem, openapi.Route{
+		Summary:     "Update cart line",
+		Description: "Set the quantity of a cart line; 0 removes it.",
+		Tag:         "Carts",
+		Body:        models.CartItemRequest{},
+		Response:    models.Cart{},
+		Errors:      []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusUnprocessableEntity},
+	})
+	g.handleFunc("DELETE /carts/{id}/items/{product_id}", h.Cart.RemoveItem, openapi.Route{
+		Summary:  "Remove cart line",
+		Tag:      "Carts",
+		Response: models.Cart{},
+		Errors:   []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict},
+	})
+	g.handleFunc("POST /carts/{id}/hold", h.Cart.Hold, openapi.Route{
+		Summary:  "Hold cart",
+		Tag:      "Carts",
+		Response: models.Cart{},
+		Errors:   []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict},
+	})
+	g.handleFunc("POST /carts/{id}/resume", h.Cart.Resume, openapi.Route{
+		Summary:  "Resume cart",
+		Tag:      "Carts",
+		Response: models.Cart{},
+		Errors:   []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict},
+	})
+	g.handleFunc("POST /carts/{id}/checkout", h.Cart.Checkout, openapi.Route{
+		Summary:      "Checkout cart",
+		Description:  "Convert the cart into a transaction.",
+		Tag:          "Carts",
+		Params:       attribution,
+		Body:         models.CartCheckoutRequest{},
+		OptionalBody: true,
+		Response:     models.Transaction{},
+		Errors:       []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusUnprocessableEntity},
+	})
+
+	// Audit
+	g.handleFunc("GET /audit", h.Audit.List, openapi.Route{
+		Summary:     "Audit log",
+		Description: "Creates, updates, deletes and restores of products, categories, price rules, modifier groups, customers, vouchers and transactions, scheduled price changes, voids and refunds, and kasbon repayments, newest first.",
+		Tag:         "Audit",
+		Params: []openapi.Parameter{
+			openapi.Query("entity", "product, category, price_rule, modifier_group, customer, voucher or transaction"),
+			openapi.Query("entity_id", "Only entries of this entity"),
+			openapi.Query("actor", "Only changes made by this cashier"),
+			openapi.Query("start_date", "Start date (YYYY-MM-DD)"),
+			openapi.Query("end_date", "End date (YYYY-MM-DD), inclusive"),
+			openapi.Query("limit", "Maximum number of entries, 100 by default and at most 500"),
+		},
+		Response: []models.AuditEntry{},
+		Errors:   []int{http.StatusUnprocessableEntity},
+	})
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"kasir-api/apperror"
+	"kasir-api/models"
+	"kasir-api/services"
+)
+
+type ShiftHandler struct {
+	service *services.ShiftService
+}
+
+func NewShiftHandler(service *services.ShiftService) *ShiftHandler {
+	return &ShiftHandler{service: service}
+}
+
+func (h *ShiftHandler) Open(w http.ResponseWriter, r *http.Request) {
+	var req models.OpenShiftRequest
+	err := decodeJSON(r, &req)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	shift, err := h.service.Open(r.Context(), req, attributionFromRequest(r))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(shift)
+}
+
+func (h *ShiftHandler) GetCurrent(w http.ResponseWriter, r *http.Request) {
+	shift, err := h.service.GetCurrent(r.Context(), attributionFromRequest(r).TerminalID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(shift)
+}
+
+func (h *ShiftHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, r, apperror.BadRequest("invalid shift ID"))
+		return
+	}
+
+	shift, err := h.service.GetByID(r.Context(), id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(shift)
+}
+
+func (h *ShiftHandler) AddCashMovement(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, r, apperror.BadRequest("invalid shift ID"))
+		return
+	}
+
+	var movement models.CashMovement
+	err = decodeJSON(r, &movement)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	movement.ShiftID = id
+	movement.CashierID = attributionFromRequest(r).CashierID
+	err = h.service.AddCashMovement(r.Context(), &movement)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(movement)
+}
+
+// X report of an open shift, or the Z report of a closed one
+func (h *ShiftHandler) Report(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, r, apperror.BadRequest("invalid shift ID"))
+		return
+	}
+
+	report, err := h.service.Report(r.Context(), id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+func (h *ShiftHandler) Close(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, r, apperror.BadRequest("invalid shift ID"))
+		return
+	}
+
+	var req models.CloseShiftRequest
+	err = decodeJSON(r, &req)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	report, err := h.service.Close(r.Context(), id, req, attributionFromRequest(r))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
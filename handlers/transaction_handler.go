@@ -1,9 +1,14 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
 
+	"kasir-api/apperror"
 	"kasir-api/models"
 	"kasir-api/services"
 )
@@ -16,26 +21,26 @@ func NewTransactionHandler(service *services.TransactionService) *TransactionHan
 	return &TransactionHandler{service: service}
 }
 
-func (h *TransactionHandler) HandleCheckout(w http.ResponseWriter, r *http.Request) {
-	switch r.Method {
-	case http.MethodPost:
-		h.Checkout(w, r)
-	default:
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+// attributionFromRequest reads the cashier, register and store identifiers sent by the POS.
+func attributionFromRequest(r *http.Request) models.Attribution {
+	return models.Attribution{
+		CashierID:  strings.TrimSpace(r.Header.Get("X-Cashier-ID")),
+		TerminalID: strings.TrimSpace(r.Header.Get("X-Terminal-ID")),
+		StoreID:    strings.TrimSpace(r.Header.Get("X-Store-ID")),
 	}
 }
 
 func (h *TransactionHandler) Checkout(w http.ResponseWriter, r *http.Request) {
 	var req models.CheckoutRequest
-	err := json.NewDecoder(r.Body).Decode(&req)
+	err := decodeJSON(r, &req)
 	if err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		writeError(w, r, err)
 		return
 	}
 
-	transaction, err := h.service.Checkout(req.Items, true)
+	transaction, err := h.service.Checkout(r.Context(), req, attributionFromRequest(r), true)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
@@ -43,21 +48,121 @@ func (h *TransactionHandler) Checkout(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(transaction)
 }
 
-func (h *TransactionHandler) HandleReport(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+func (h *TransactionHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, r, apperror.BadRequest("invalid transaction ID"))
+		return
+	}
+
+	transaction, err := h.service.GetByID(r.Context(), id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(transaction)
+}
+
+func (h *TransactionHandler) Receipt(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, r, apperror.BadRequest("invalid transaction ID"))
+		return
+	}
+
+	receipt, err := h.service.Receipt(r.Context(), id)
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	io.WriteString(w, receipt)
+}
+
+func (h *TransactionHandler) Void(w http.ResponseWriter, r *http.Request) {
+	h.changeStatus(w, r, h.service.Void)
+}
+
+func (h *TransactionHandler) Refund(w http.ResponseWriter, r *http.Request) {
+	h.changeStatus(w, r, h.service.Refund)
+}
+
+type statusChangeFunc func(ctx context.Context, id int, attr models.Attribution, reason string) (*models.Transaction, error)
+
+func (h *TransactionHandler) changeStatus(w http.ResponseWriter, r *http.Request, change statusChangeFunc) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, r, apperror.BadRequest("invalid transaction ID"))
+		return
+	}
+
+	var req models.StatusChangeRequest
+	if r.ContentLength != 0 {
+		if err := decodeJSON(r, &req); err != nil {
+			writeError(w, r, err)
+			return
+		}
+	}
+
+	transaction, err := change(r.Context(), id, attributionFromRequest(r), req.Reason)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(transaction)
+}
+
+func (h *TransactionHandler) SalesSummary(w http.ResponseWriter, r *http.Request) {
 	startDate := r.URL.Query().Get("start_date")
 	endDate := r.URL.Query().Get("end_date")
 
-	summary, err := h.service.GetReport(startDate, endDate)
+	summary, err := h.service.GetReport(r.Context(), startDate, endDate)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, r, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(summary)
 }
+
+func (h *TransactionHandler) ProductReport(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	report, err := h.service.GetProductReport(r.Context(), query.Get("start_date"), query.Get("end_date"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+func (h *TransactionHandler) CashierReport(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	report, err := h.service.GetCashierReport(r.Context(), query.Get("start_date"), query.Get("end_date"), query.Get("store_id"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+func (h *TransactionHandler) TerminalReport(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	report, err := h.service.GetTerminalReport(r.Context(), query.Get("start_date"), query.Get("end_date"), query.Get("store_id"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
@@ -1,28 +1,98 @@
 package main
 
 import (
-	"encoding/json"
-	"fmt"
+	"context"
+	"errors"
 	"kasir-api/database"
 	"kasir-api/handlers"
+	"kasir-api/logging"
+	"kasir-api/metrics"
+	"kasir-api/middleware"
+	"kasir-api/models"
+	"kasir-api/ratelimit"
 	"kasir-api/repositories"
 	"kasir-api/services"
-	"log"
+	"kasir-api/tracing"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
+	"time"
+	_ "time/tzdata" // store timezone on hosts without a zoneinfo database
 
 	"github.com/spf13/viper"
 )
 
+// version is set at build time with -ldflags "-X main.version=..."
+var version = "dev"
+
 type Config struct {
-	Port   string `mapstructure:"PORT"`
-	DBConn string `mapstructure:"DB_CONN"`
+	Port             string        `mapstructure:"PORT"`
+	DBConn           string        `mapstructure:"DB_CONN"`
+	RequireOpenShift bool          `mapstructure:"REQUIRE_OPEN_SHIFT"`
+	CartReservation  time.Duration `mapstructure:"CART_RESERVATION_TTL"`
+	StoreTimezone    string        `mapstructure:"STORE_TIMEZONE"` // IANA name; price rule times are in it
+
+	// loyalty points; 0 turns earning or redeeming off
+	LoyaltyRupiahPerPoint int `mapstructure:"LOYALTY_RUPIAH_PER_POINT"` // amount paid that earns one point
+	LoyaltyPointValue     int `mapstructure:"LOYALTY_POINT_VALUE"`      // Rupiah off per redeemed point
+
+	// HTTP server
+	ReadTimeout       time.Duration `mapstructure:"HTTP_READ_TIMEOUT"`
+	ReadHeaderTimeout time.Duration `mapstructure:"HTTP_READ_HEADER_TIMEOUT"`
+	WriteTimeout      time.Duration `mapstructure:"HTTP_WRITE_TIMEOUT"`
+	IdleTimeout       time.Duration `mapstructure:"HTTP_IDLE_TIMEOUT"`
+	MaxHeaderBytes    int           `mapstructure:"HTTP_MAX_HEADER_BYTES"`
+	ShutdownTimeout   time.Duration `mapstructure:"SHUTDOWN_TIMEOUT"`
+	ReadinessTimeout  time.Duration `mapstructure:"READINESS_TIMEOUT"`
+	MaxBodyBytes      int64         `mapstructure:"HTTP_MAX_BODY_BYTES"`
+
+	// CORS; lists are comma-separated, no origins disables CORS
+	CORSAllowedOrigins []string      `mapstructure:"CORS_ALLOWED_ORIGINS"`
+	CORSAllowedMethods []string      `mapstructure:"CORS_ALLOWED_METHODS"`
+	CORSAllowedHeaders []string      `mapstructure:"CORS_ALLOWED_HEADERS"`
+	CORSMaxAge         time.Duration `mapstructure:"CORS_MAX_AGE"`
+
+	// token-bucket limits as requests/period[/burst]; routes as "GET /report/cashiers=10/1m;..."
+	RateLimitDefault string `mapstructure:"RATE_LIMIT_DEFAULT"`
+	RateLimitRoutes  string `mapstructure:"RATE_LIMIT_ROUTES"`
+	// reverse proxies (IPs or CIDR ranges, comma-separated) whose X-Forwarded-For is believed
+	TrustedProxies []string `mapstructure:"TRUSTED_PROXIES"`
+
+	// unversioned /api aliases of /api/v1
+	LegacyAPIDeprecatedAt time.Time `mapstructure:"LEGACY_API_DEPRECATED_AT"`
+	LegacyAPISunset       time.Time `mapstructure:"LEGACY_API_SUNSET"`
+
+	LogLevel      string `mapstructure:"LOG_LEVEL"`
+	TraceExporter string `mapstructure:"OTEL_TRACES_EXPORTER"` // otlp, stdout or none
 }
 
 func main() {
 	viper.AutomaticEnv()
 	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	viper.SetDefault("CART_RESERVATION_TTL", "15m")
+	viper.SetDefault("STORE_TIMEZONE", "Asia/Jakarta")
+	viper.SetDefault("LOYALTY_RUPIAH_PER_POINT", 10000)
+	viper.SetDefault("LOYALTY_POINT_VALUE", 100)
+	viper.SetDefault("HTTP_READ_TIMEOUT", "10s")
+	viper.SetDefault("HTTP_READ_HEADER_TIMEOUT", "5s")
+	viper.SetDefault("HTTP_WRITE_TIMEOUT", "30s")
+	viper.SetDefault("HTTP_IDLE_TIMEOUT", "120s")
+	viper.SetDefault("HTTP_MAX_HEADER_BYTES", 1<<20)
+	viper.SetDefault("SHUTDOWN_TIMEOUT", "20s")
+	viper.SetDefault("READINESS_TIMEOUT", "2s")
+	viper.SetDefault("HTTP_MAX_BODY_BYTES", 1<<20)
+	viper.SetDefault("CORS_ALLOWED_METHODS", "GET,POST,PUT,DELETE")
+	viper.SetDefault("CORS_ALLOWED_HEADERS", "Content-Type,X-Request-ID,X-Cashier-ID,X-Terminal-ID,X-Store-ID")
+	viper.SetDefault("CORS_MAX_AGE", "10m")
+	viper.SetDefault("RATE_LIMIT_DEFAULT", "300/1m/60")
+	viper.SetDefault("RATE_LIMIT_ROUTES", "GET /report/sales-summary=10/1m;GET /report/products=10/1m;GET /report/cashiers=10/1m;GET /report/terminals=10/1m;GET /report/receivables-aging=10/1m")
+	viper.SetDefault("LEGACY_API_DEPRECATED_AT", "2026-10-19")
+	viper.SetDefault("LEGACY_API_SUNSET", "2027-04-30")
+	viper.SetDefault("LOG_LEVEL", "info")
+	viper.SetDefault("OTEL_TRACES_EXPORTER", "none")
 
 	if _, err := os.Stat(".env"); err == nil {
 		viper.SetConfigFile(".env")
@@ -30,67 +100,271 @@ func main() {
 	}
 
 	config := Config{
-		Port:   viper.GetString("PORT"),
-		DBConn: viper.GetString("DB_CONN"),
+		Port:             viper.GetString("PORT"),
+		DBConn:           viper.GetString("DB_CONN"),
+		RequireOpenShift: viper.GetBool("REQUIRE_OPEN_SHIFT"),
+		CartReservation:  viper.GetDuration("CART_RESERVATION_TTL"),
+		StoreTimezone:    viper.GetString("STORE_TIMEZONE"),
+
+		LoyaltyRupiahPerPoint: viper.GetInt("LOYALTY_RUPIAH_PER_POINT"),
+		LoyaltyPointValue:     viper.GetInt("LOYALTY_POINT_VALUE"),
+
+		ReadTimeout:       viper.GetDuration("HTTP_READ_TIMEOUT"),
+		ReadHeaderTimeout: viper.GetDuration("HTTP_READ_HEADER_TIMEOUT"),
+		WriteTimeout:      viper.GetDuration("HTTP_WRITE_TIMEOUT"),
+		IdleTimeout:       viper.GetDuration("HTTP_IDLE_TIMEOUT"),
+		MaxHeaderBytes:    viper.GetInt("HTTP_MAX_HEADER_BYTES"),
+		ShutdownTimeout:   viper.GetDuration("SHUTDOWN_TIMEOUT"),
+		ReadinessTimeout:  viper.GetDuration("READINESS_TIMEOUT"),
+		MaxBodyBytes:      viper.GetInt64("HTTP_MAX_BODY_BYTES"),
+
+		CORSAllowedOrigins: splitList(viper.GetString("CORS_ALLOWED_ORIGINS")),
+		CORSAllowedMethods: splitList(viper.GetString("CORS_ALLOWED_METHODS")),
+		CORSAllowedHeaders: splitList(viper.GetString("CORS_ALLOWED_HEADERS")),
+		CORSMaxAge:         viper.GetDuration("CORS_MAX_AGE"),
+
+		RateLimitDefault: viper.GetString("RATE_LIMIT_DEFAULT"),
+		RateLimitRoutes:  viper.GetString("RATE_LIMIT_ROUTES"),
+		TrustedProxies:   splitList(viper.GetString("TRUSTED_PROXIES")),
+
+		LegacyAPIDeprecatedAt: viper.GetTime("LEGACY_API_DEPRECATED_AT"),
+		LegacyAPISunset:       viper.GetTime("LEGACY_API_SUNSET"),
+
+		LogLevel:      viper.GetString("LOG_LEVEL"),
+		TraceExporter: viper.GetString("OTEL_TRACES_EXPORTER"),
+	}
+
+	logging.Setup(os.Stdout, config.LogLevel)
+
+	shutdownTracing, err := tracing.Setup(context.Background(), config.TraceExporter, "kasir-api", version)
+	if err != nil {
+		slog.Error("failed to set up tracing", slog.Any("error", err))
+		os.Exit(1)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			slog.Error("failed to flush traces", slog.Any("error", err))
+		}
+	}()
+
+	storeLocation, err := time.LoadLocation(config.StoreTimezone)
+	if err != nil {
+		slog.Error("invalid store timezone", slog.String("timezone", config.StoreTimezone), slog.Any("error", err))
+		os.Exit(1)
 	}
 
 	// set up database
 	db, err := database.InitDB(config.DBConn)
 	if err != nil {
-		log.Fatal("failed to initialize database:", err)
+		slog.Error("failed to initialize database", slog.Any("error", err))
+		os.Exit(1)
 	}
 	defer db.Close()
 
+	if err := database.Migrate(db); err != nil {
+		slog.Error("failed to run migrations", slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	metrics.RegisterDB(db)
+
+	// =====================
+	// CATEGORY SETUP
+	// =====================
+
+	categoryRepo := repositories.NewCategoryRepository(db)
+	categoryService := services.NewCategoryService(categoryRepo)
+	categoryHandler := handlers.NewCategoryHandler(categoryService)
+
 	// =====================
 	// PRODUCT SETUP
 	// =====================
 
 	productRepo := repositories.NewProductRepository(db)
-	productService := services.NewProductService(productRepo)
+	priceRepo := repositories.NewPriceRepository(db)
+	bundleRepo := repositories.NewBundleRepository(db)
+	productService := services.NewProductService(productRepo, categoryRepo, priceRepo, bundleRepo)
 	productHandler := handlers.NewProductHandler(productService)
 
-	// Product routes
-	http.HandleFunc("/api/products", productHandler.HandleProducts)     // GET & POST
-	http.HandleFunc("/api/products/", productHandler.HandleProductByID) // GET, PUT, DELETE
+	// =====================
+	// PRICE RULE SETUP
+	// =====================
+
+	priceRuleRepo := repositories.NewPriceRuleRepository(db)
+	priceRuleService := services.NewPriceRuleService(priceRuleRepo, productRepo, categoryRepo)
+	priceRuleHandler := handlers.NewPriceRuleHandler(priceRuleService)
 
 	// =====================
-	// CATEGORY SETUP
+	// MODIFIER SETUP
 	// =====================
 
-	categoryRepo := repositories.NewCategoryRepository(db)
-	categoryService := services.NewCategoryService(categoryRepo)
-	categoryHandler := handlers.NewCategoryHandler(categoryService)
+	modifierRepo := repositories.NewModifierRepository(db)
+	modifierService := services.NewModifierService(modifierRepo, productRepo, categoryRepo)
+	modifierHandler := handlers.NewModifierHandler(modifierService)
 
-	// Category routes
-	http.HandleFunc("/api/categories", categoryHandler.HandleCategories)    // GET & POST
-	http.HandleFunc("/api/categories/", categoryHandler.HandleCategoryByID) // GET, PUT, DELETE
+	// =====================
+	// CUSTOMER SETUP
+	// =====================
+
+	loyalty := models.LoyaltyProgram{RupiahPerPoint: config.LoyaltyRupiahPerPoint, PointValue: config.LoyaltyPointValue}
+	customerRepo := repositories.NewCustomerRepository(db)
+	customerService := services.NewCustomerService(customerRepo, loyalty)
+	customerHandler := handlers.NewCustomerHandler(customerService)
+
+	// =====================
+	// VOUCHER SETUP
+	// =====================
+
+	voucherRepo := repositories.NewVoucherRepository(db)
+	voucherService := services.NewVoucherService(voucherRepo)
+	voucherHandler := handlers.NewVoucherHandler(voucherService)
+
+	// =====================
+	// SHIFT SETUP
+	// =====================
+	shiftRepo := repositories.NewShiftRepository(db)
+	shiftService := services.NewShiftService(shiftRepo)
+	shiftHandler := handlers.NewShiftHandler(shiftService)
 
 	// =====================
 	// TRANSACTION SETUP
 	// =====================
 	transactionRepo := repositories.NewTransactionRepository(db)
-	transactionService := services.NewTransactionService(transactionRepo)
+	transactionService := services.NewTransactionService(transactionRepo, shiftRepo, config.RequireOpenShift, storeLocation, loyalty)
 	transactionHandler := handlers.NewTransactionHandler(transactionService)
 
-	http.HandleFunc("/api/checkout", transactionHandler.HandleCheckout) // POST
-	http.HandleFunc("/api/report/sales-summary", transactionHandler.HandleReport)
+	// =====================
+	// RECEIVABLE SETUP
+	// =====================
+	receivableRepo := repositories.NewReceivableRepository(db)
+	receivableService := services.NewReceivableService(receivableRepo, customerRepo, shiftRepo, storeLocation)
+	receivableHandler := handlers.NewReceivableHandler(receivableService)
+
+	// =====================
+	// CART SETUP
+	// =====================
+	cartRepo := repositories.NewCartRepository(db)
+	cartService := services.NewCartService(cartRepo, productRepo, transactionService, config.CartReservation)
+	cartHandler := handlers.NewCartHandler(cartService)
+
+	// =====================
+	// AUDIT SETUP
+	// =====================
+	auditRepo := repositories.NewAuditRepository(db)
+	auditService := services.NewAuditService(auditRepo)
+	auditHandler := handlers.NewAuditHandler(auditService)
+
+	// =====================
+	// HEALTH CHECKS
+	// =====================
+	healthHandler := handlers.NewHealthHandler(db, version, config.ReadinessTimeout)
+
+	rateLimits, err := rateLimitPolicy(config)
+	if err != nil {
+		slog.Error("invalid rate limit configuration", slog.Any("error", err))
+		os.Exit(1)
+	}
+	trustedProxies, err := ratelimit.ParseProxies(config.TrustedProxies)
+	if err != nil {
+		slog.Error("invalid trusted proxies", slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	router := handlers.NewRouter(handlers.Handlers{
+		Health:      healthHandler,
+		Category:    categoryHandler,
+		Product:     productHandler,
+		PriceRule:   priceRuleHandler,
+		Modifier:    modifierHandler,
+		Customer:    customerHandler,
+		Voucher:     voucherHandler,
+		Receivable:  receivableHandler,
+		Transaction: transactionHandler,
+		Shift:       shiftHandler,
+		Cart:        cartHandler,
+		Audit:       auditHandler,
+		Metrics:     metrics.Handler(),
+	}, handlers.RouterOptions{
+		Legacy:         middleware.Deprecation(config.LegacyAPIDeprecatedAt, config.LegacyAPISunset, "/api", "/api/v1"),
+		Limiter:        ratelimit.NewMemory(),
+		RateLimits:     rateLimits,
+		TrustedProxies: trustedProxies,
+	},
+		middleware.RequestID, middleware.Actor, middleware.Tracing, middleware.AccessLog, middleware.Metrics,
+		middleware.SecurityHeaders,
+		middleware.CORS(middleware.CORSConfig{
+			AllowedOrigins: config.CORSAllowedOrigins,
+			AllowedMethods: config.CORSAllowedMethods,
+			AllowedHeaders: config.CORSAllowedHeaders,
+			ExposedHeaders: []string{"X-Request-ID", "Deprecation", "Sunset", "Link", "Retry-After",
+				"RateLimit-Policy", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset"},
+			MaxAge: config.CORSMaxAge,
+		}),
+		middleware.BodyLimit(config.MaxBodyBytes),
+	)
 
-	// Health Check
-	http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]string{
-			"status":  "OK",
-			"message": "API Running",
-		})
-	})
+	server := &http.Server{
+		Addr:              "0.0.0.0:" + config.Port,
+		Handler:           router,
+		ErrorLog:          slog.NewLogLogger(slog.Default().Handler(), slog.LevelWarn),
+		ReadTimeout:       config.ReadTimeout,
+		ReadHeaderTimeout: config.ReadHeaderTimeout,
+		WriteTimeout:      config.WriteTimeout,
+		IdleTimeout:       config.IdleTimeout,
+		MaxHeaderBytes:    config.MaxHeaderBytes,
+	}
 
-	fmt.Println("Server running at http://localhost:" + config.Port)
+	// stop accepting requests on SIGINT/SIGTERM, then let in-flight ones finish
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	addr := "0.0.0.0:" + config.Port
-	fmt.Println("Starting server at", addr)
+	serverErr := make(chan error, 1)
+	go func() {
+		slog.Info("starting server", slog.String("addr", server.Addr), slog.String("version", version))
+		serverErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("failed to start server", slog.Any("error", err))
+		}
+		return
+	case <-ctx.Done():
+	}
+	stop()
 
-	err = http.ListenAndServe(addr, nil)
+	slog.Info("shutting down, draining in-flight requests", slog.Duration("timeout", config.ShutdownTimeout))
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		slog.Error("graceful shutdown did not complete", slog.Any("error", err))
+	}
+	slog.Info("server stopped")
+}
+
+// splitList parses a comma-separated config value, dropping empty entries.
+func splitList(value string) []string {
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+func rateLimitPolicy(config Config) (ratelimit.Policy, error) {
+	defaultLimit, err := ratelimit.ParseLimit(config.RateLimitDefault)
+	if err != nil {
+		return ratelimit.Policy{}, err
+	}
+	routes, err := ratelimit.ParseRoutes(config.RateLimitRoutes)
 	if err != nil {
-		fmt.Println("Failed to start server:", err)
+		return ratelimit.Policy{}, err
 	}
+	return ratelimit.Policy{Default: defaultLimit, Routes: routes}, nil
 }
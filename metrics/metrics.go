@@ -0,0 +1,82 @@
+// Package metrics holds the Prometheus collectors exposed on /metrics.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+
+	"kasir-api/apperror"
+	"kasir-api/models"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "kasir"
+
+var (
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by route, method and status code.",
+	}, []string{"route", "method", "status"})
+
+	HTTPDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route, method and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	checkouts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "checkouts_total",
+		Help:      "Checkout attempts by outcome.",
+	}, []string{"outcome"})
+
+	revenue = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "revenue_ingested_rupiah_total",
+		Help:      "Total amount of successful checkouts, in Rupiah.",
+	})
+)
+
+// Checkout outcome labels
+const (
+	OutcomeSuccess           = "success"
+	OutcomeInsufficientStock = "insufficient_stock"
+	OutcomeNotFound          = "not_found"
+	OutcomeInvalid           = "invalid"
+	OutcomeError             = "error"
+)
+
+// RegisterDB exports the connection pool stats of db.
+func RegisterDB(db *sql.DB) {
+	prometheus.MustRegister(collectors.NewDBStatsCollector(db, "postgres"))
+}
+
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// ObserveCheckout records the outcome of a checkout and, on success, its revenue.
+func ObserveCheckout(transaction *models.Transaction, err error) {
+	if err == nil {
+		checkouts.WithLabelValues(OutcomeSuccess).Inc()
+		revenue.Add(float64(transaction.TotalAmount))
+		return
+	}
+
+	switch apperror.CodeOf(err) {
+	case apperror.CodeInsufficientStock:
+		checkouts.WithLabelValues(OutcomeInsufficientStock).Inc()
+	case apperror.CodeNotFound:
+		checkouts.WithLabelValues(OutcomeNotFound).Inc()
+	case apperror.CodeValidation, apperror.CodeBadRequest, apperror.CodeConflict:
+		checkouts.WithLabelValues(OutcomeInvalid).Inc()
+	default:
+		checkouts.WithLabelValues(OutcomeError).Inc()
+	}
+}
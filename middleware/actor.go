@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"kasir-api/audit"
+)
+
+// Actor records the cashier named in X-Cashier-ID as the user acting on the
+// request, for the audit log.
+func Actor(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		actor := strings.TrimSpace(r.Header.Get("X-Cashier-ID"))
+		if actor != "" {
+			r = r.WithContext(audit.WithActor(r.Context(), actor))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
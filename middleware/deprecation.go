@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Deprecation marks every response as coming from a deprecated API
+// (RFC 9745 Deprecation, RFC 8594 Sunset) and links the successor path,
+// obtained by swapping oldPrefix for newPrefix in the request path.
+func Deprecation(deprecatedAt, sunset time.Time, oldPrefix, newPrefix string) Middleware {
+	deprecation := fmt.Sprintf("@%d", deprecatedAt.Unix())
+	sunsetDate := sunset.UTC().Format(http.TimeFormat)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Deprecation", deprecation)
+			w.Header().Set("Sunset", sunsetDate)
+			if rest, ok := strings.CutPrefix(r.URL.Path, oldPrefix); ok {
+				w.Header().Set("Link", fmt.Sprintf("<%s%s>; rel=\"successor-version\"", newPrefix, rest))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"kasir-api/tracing/tracingtest"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracingNamesSpanAfterRoute(t *testing.T) {
+	exporter := tracingtest.Record(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /products/{id}", func(w http.ResponseWriter, r *http.Request) {})
+
+	rec := httptest.NewRecorder()
+	Tracing(mux).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/products/7", nil))
+
+	span := tracingtest.Find(t, exporter, "GET /products/{id}")
+	if span.SpanKind != trace.SpanKindServer {
+		t.Errorf("span kind = %v, want server", span.SpanKind)
+	}
+	if got := tracingtest.Attribute(span, "http.route"); got != "GET /products/{id}" {
+		t.Errorf("http.route = %v, want GET /products/{id}", got)
+	}
+	if got := tracingtest.Attribute(span, "url.path"); got != "/products/7" {
+		t.Errorf("url.path = %v, want /products/7", got)
+	}
+	if got := tracingtest.Attribute(span, "http.response.status_code"); got != int64(http.StatusOK) {
+		t.Errorf("http.response.status_code = %v, want 200", got)
+	}
+}
+
+func TestTracingKeepsMethodForUnmatchedRoute(t *testing.T) {
+	exporter := tracingtest.Record(t)
+
+	rec := httptest.NewRecorder()
+	Tracing(http.NewServeMux()).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/missing", nil))
+
+	span := tracingtest.Find(t, exporter, http.MethodGet)
+	if got := tracingtest.Attribute(span, "http.route"); got != nil {
+		t.Errorf("http.route = %v, want unset", got)
+	}
+}
+
+func TestTracingMarksServerErrors(t *testing.T) {
+	exporter := tracingtest.Record(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /checkout", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	rec := httptest.NewRecorder()
+	Tracing(mux).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/checkout", nil))
+
+	span := tracingtest.Find(t, exporter, "POST /checkout")
+	if span.Status.Code != codes.Error {
+		t.Errorf("status = %v, want error", span.Status.Code)
+	}
+}
+
+func TestTracingContinuesPropagatedTrace(t *testing.T) {
+	exporter := tracingtest.Record(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /livez", func(w http.ResponseWriter, r *http.Request) {})
+
+	req := httptest.NewRequest(http.MethodGet, "/livez", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	Tracing(mux).ServeHTTP(httptest.NewRecorder(), req)
+
+	span := tracingtest.Find(t, exporter, "GET /livez")
+	if got := span.SpanContext.TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("trace ID = %s, want the propagated one", got)
+	}
+	if got := span.Parent.SpanID().String(); got != "00f067aa0ba902b7" {
+		t.Errorf("parent span ID = %s, want the propagated one", got)
+	}
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	AuditEntityProduct       = "product"
+	AuditEntityCategory      = "category"
+	AuditEntityTransaction   = "transaction"
+	AuditEntityPriceRule     = "price_rule"
+	AuditEntityModifierGroup = "modifier_group"
+	AuditEntityCustomer      = "customer"
+	AuditEntityVoucher       = "voucher"
+
+	AuditActionCreate        = "create"
+	AuditActionUpdate        = "update"
+	AuditActionDelete        = "delete"
+	AuditActionRestore       = "restore"
+	AuditActionVoid          = "void"
+	AuditActionRefund        = "refund"
+	AuditActionSchedulePrice = "schedule_price"
+	AuditActionCancelPrice   = "cancel_price"
+	AuditActionRepayment     = "repayment"
+)
+
+type AuditEntry struct {
+	ID        int64           `json:"id"`
+	Entity    string          `json:"entity"`
+	EntityID  int             `json:"entity_id"`
+	Action    string          `json:"action"`
+	Actor     string          `json:"actor"`
+	RequestID string          `json:"request_id,omitempty"`
+	Before    json.RawMessage `json:"before,omitempty"`
+	After     json.RawMessage `json:"after,omitempty"`
+	Changes   json.RawMessage `json:"changes,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type AuditFilter struct {
+	Entity   string
+	EntityID int
+	Actor    string
+	Start    *time.Time
+	End      *time.Time
+	Limit    int
+}
@@ -0,0 +1,39 @@
+package models
+
+// Bundle is what a bundle product is made of. Its stock is derived from the
+// components, and selling it deducts the components.
+type Bundle struct {
+	ProductID    int                 `json:"product_id"`
+	Components   []BundleComponent   `json:"components"`
+	ChoiceGroups []BundleChoiceGroup `json:"choice_groups"`
+	Available    int                 `json:"available"` // bundles that can be made from current stock
+}
+
+// BundleComponent is a product included in every bundle, Quantity per bundle.
+type BundleComponent struct {
+	ProductID   int    `json:"product_id"`
+	ProductName string `json:"product_name,omitempty"`
+	Quantity    int    `json:"quantity"`
+}
+
+// BundleChoiceGroup lets the customer pick Quantity products of a category,
+// e.g. any drink from Beverages.
+type BundleChoiceGroup struct {
+	ID         int    `json:"id"`
+	Name       string `json:"name"`
+	CategoryID int    `json:"category_id"`
+	Quantity   int    `json:"quantity"`
+}
+
+type SetBundleRequest struct {
+	Components   []BundleComponent   `json:"components"`
+	ChoiceGroups []BundleChoiceGroup `json:"choice_groups"`
+}
+
+// ProductSales is what a product sold, including its share of bundles.
+type ProductSales struct {
+	ProductID int    `json:"product_id"`
+	Name      string `json:"name"`
+	Quantity  int    `json:"quantity"`
+	Revenue   int    `json:"revenue"`
+}
@@ -0,0 +1,64 @@
+package models
+
+import "time"
+
+const (
+	CartStatusActive    = "active"
+	CartStatusHeld      = "held"
+	CartStatusConverted = "converted"
+	CartStatusCancelled = "cancelled"
+)
+
+type Cart struct {
+	ID            int        `json:"id"`
+	Status        string     `json:"status"`
+	CashierID     string     `json:"cashier_id"`
+	TerminalID    string     `json:"terminal_id"`
+	StoreID       string     `json:"store_id,omitempty"`
+	Note          string     `json:"note,omitempty"`
+	ReserveStock  bool       `json:"reserve_stock"`
+	ReservedUntil *time.Time `json:"reserved_until,omitempty"`
+	TransactionID *int       `json:"transaction_id,omitempty"`
+	Items         []CartItem `json:"items"`
+	TotalAmount   int        `json:"total_amount"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// Why a cart line cannot be checked out
+const (
+	CartItemDeleted    = "deleted"      // the product was deleted
+	CartItemOutOfStock = "out_of_stock" // there is less stock than the line's quantity
+)
+
+// Cart line priced as checkout would price it now: the product's current
+// price less the best price rule. A cart holds no modifiers, so none are
+// charged. Unavailable lines are not in the cart total.
+type CartItem struct {
+	ProductID   int    `json:"product_id"`
+	ProductName string `json:"product_name"`
+	Price       int    `json:"price"`
+	Quantity    int    `json:"quantity"`
+	Discount    int    `json:"discount"`
+	PriceRuleID *int   `json:"price_rule_id,omitempty"`
+	Subtotal    int    `json:"subtotal"`
+	Stock       int    `json:"stock"`
+	Unavailable string `json:"unavailable,omitempty"`
+}
+
+type CreateCartRequest struct {
+	Note         string `json:"note"`
+	ReserveStock bool   `json:"reserve_stock"`
+}
+
+type CartItemRequest struct {
+	ProductID int `json:"product_id"`
+	Quantity  int `json:"quantity"`
+}
+
+type CartCheckoutRequest struct {
+	PaymentMethod string `json:"payment_method"`
+	CustomerID    *int   `json:"customer_id,omitempty"`
+	RedeemPoints  int    `json:"redeem_points,omitempty"`
+	VoucherCode   string `json:"voucher_code,omitempty"`
+}
@@ -1,7 +1,11 @@
 package models
 
+import "time"
+
 type Category struct {
-	ID          int    `json:"id"`
-	Name        string `json:"name"`
-	Description string `json:"description"`
+	ID          int        `json:"id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Active      bool       `json:"active"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
 }
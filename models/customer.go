@@ -0,0 +1,64 @@
+package models
+
+import "time"
+
+const (
+	LoyaltyEntryEarn     = "earn"
+	LoyaltyEntryRedeem   = "redeem"
+	LoyaltyEntryReversal = "reversal" // points given back or taken back by a void or refund
+)
+
+// Customer is a member a sale can be attached to. MemberCode is generated
+// when left empty on create; Points is the current loyalty balance.
+// CreditLimit caps what the customer may owe on kasbon, Receivable is what
+// they owe now.
+type Customer struct {
+	ID          int        `json:"id"`
+	Name        string     `json:"name"`
+	Phone       string     `json:"phone"`
+	Email       string     `json:"email"`
+	MemberCode  string     `json:"member_code"`
+	Points      int        `json:"points"`
+	CreditLimit int        `json:"credit_limit"`
+	Receivable  int        `json:"receivable"`
+	Active      bool       `json:"active"`
+	CreatedAt   time.Time  `json:"created_at"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+}
+
+// LoyaltyProgram is how points are earned and what they are worth.
+// A zero field turns earning or redeeming off.
+type LoyaltyProgram struct {
+	RupiahPerPoint int // amount paid that earns one point
+	PointValue     int // discount in Rupiah per redeemed point
+}
+
+// LoyaltyEntry is a change to a customer's points; Points is negative when
+// points are spent or taken back.
+type LoyaltyEntry struct {
+	ID            int       `json:"id"`
+	TransactionID *int      `json:"transaction_id,omitempty"`
+	Type          string    `json:"type"`
+	Points        int       `json:"points"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type LoyaltyAccount struct {
+	CustomerID int            `json:"customer_id"`
+	Balance    int            `json:"balance"`
+	PointValue int            `json:"point_value"` // Rupiah per point at checkout
+	Entries    []LoyaltyEntry `json:"entries"`
+}
+
+// CustomerPurchase is a transaction in a customer's purchase history;
+// GET /transactions/{id} has its lines.
+type CustomerPurchase struct {
+	TransactionID  int       `json:"transaction_id"`
+	TotalAmount    int       `json:"total_amount"`
+	PaymentMethod  string    `json:"payment_method"`
+	Status         string    `json:"status"`
+	Items          int       `json:"items"`
+	PointsRedeemed int       `json:"points_redeemed"`
+	PointsEarned   int       `json:"points_earned"`
+	CreatedAt      time.Time `json:"created_at"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// ModifierGroup offers options such as "extra shot" or "oat milk" on a
+// product, or on every product of a category. A checkout line picks between
+// MinSelect and MaxSelect of its options.
+type ModifierGroup struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	ProductID  *int       `json:"product_id,omitempty"`
+	CategoryID *int       `json:"category_id,omitempty"`
+	MinSelect  int        `json:"min_select"`
+	MaxSelect  int        `json:"max_select"`
+	Options    []Modifier `json:"options"`
+	CreatedAt  time.Time  `json:"created_at"`
+	DeletedAt  *time.Time `json:"deleted_at,omitempty"`
+}
+
+// Modifier is an option of a group; PriceDelta is added to the unit price
+// and may be negative.
+type Modifier struct {
+	ID         int    `json:"id"`
+	Name       string `json:"name"`
+	PriceDelta int    `json:"price_delta"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// ProductPrice is a price of a product from EffectiveAt until the next entry
+// takes over. Entries in the future are scheduled changes.
+type ProductPrice struct {
+	ID          int       `json:"id"`
+	ProductID   int       `json:"product_id"`
+	Price       int       `json:"price"`
+	EffectiveAt time.Time `json:"effective_at"`
+	CreatedBy   string    `json:"created_by,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type SchedulePriceRequest struct {
+	Price       int       `json:"price"`
+	EffectiveAt time.Time `json:"effective_at"`
+}
+
+type PriceHistory struct {
+	ProductID    int            `json:"product_id"`
+	CurrentPrice int            `json:"current_price"`
+	History      []ProductPrice `json:"history"`  // newest first, the current price on top
+	Upcoming     []ProductPrice `json:"upcoming"` // soonest first
+}
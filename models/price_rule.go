@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+const (
+	PriceRulePercentOff = "percent_off" // Value percent off the unit price
+	PriceRuleAmountOff  = "amount_off"  // Value Rupiah off the unit price
+	PriceRuleFixedPrice = "fixed_price" // unit price of Value
+)
+
+// Weekdays are the day names used by price rules, indexed by time.Weekday.
+var Weekdays = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// PriceRule changes the unit price of a product, or of every product in a
+// category, on the given days between StartTime and EndTime (HH:MM, store
+// time, end exclusive). A window with EndTime before StartTime runs past
+// midnight into the day after each of its days.
+type PriceRule struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	ProductID  *int       `json:"product_id,omitempty"`
+	CategoryID *int       `json:"category_id,omitempty"`
+	Type       string     `json:"type"`
+	Value      int        `json:"value"`
+	Days       []string   `json:"days"`
+	StartTime  string     `json:"start_time"`
+	EndTime    string     `json:"end_time"`
+	CreatedAt  time.Time  `json:"created_at"`
+	DeletedAt  *time.Time `json:"deleted_at,omitempty"`
+}
+
+// Apply returns the unit price under the rule, never below zero.
+func (r PriceRule) Apply(price int) int {
+	switch r.Type {
+	case PriceRulePercentOff:
+		price -= price * r.Value / 100
+	case PriceRuleAmountOff:
+		price -= r.Value
+	case PriceRuleFixedPrice:
+		price = r.Value
+	}
+	return max(price, 0)
+}
@@ -1,10 +1,17 @@
 package models
 
+import "time"
+
 type Product struct {
-	ID         int      `json:"id"`
-	Name       string   `json:"name"`
-	Price      int      `json:"price"`
-	Stock      int      `json:"stock"`
-	CategoryId int      `json:"category_id"`
-	Category   Category `json:"category"`
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	SKU        string     `json:"sku"`
+	Price      int        `json:"price"`
+	Cost       int        `json:"cost"`
+	Stock      int        `json:"stock"` // derived from the components for bundles
+	IsBundle   bool       `json:"is_bundle"`
+	CategoryId int        `json:"category_id"`
+	Category   Category   `json:"category"`
+	Active     bool       `json:"active"`
+	DeletedAt  *time.Time `json:"deleted_at,omitempty"`
 }
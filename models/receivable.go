@@ -0,0 +1,72 @@
+package models
+
+import "time"
+
+const (
+	ReceivableEntryCharge  = "charge"  // a kasbon sale
+	ReceivableEntryPayment = "payment" // a repayment
+)
+
+// ReceivablePayment is money a customer paid towards their kasbon.
+type ReceivablePayment struct {
+	ID            int       `json:"id"`
+	CustomerID    int       `json:"customer_id"`
+	Amount        int       `json:"amount"`
+	PaymentMethod string    `json:"payment_method"`
+	ShiftID       *int      `json:"shift_id,omitempty"`
+	CashierID     string    `json:"cashier_id"`
+	Note          string    `json:"note,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type RepaymentRequest struct {
+	Amount        int    `json:"amount"`
+	PaymentMethod string `json:"payment_method"` // cash when empty
+	Note          string `json:"note"`
+}
+
+type CreditLimitRequest struct {
+	CreditLimit int `json:"credit_limit"`
+}
+
+// ReceivableEntry is a line of a customer's receivables ledger. Amount is
+// positive for charges and negative for payments; Balance is what the
+// customer owed after it.
+type ReceivableEntry struct {
+	Type          string    `json:"type"`
+	TransactionID *int      `json:"transaction_id,omitempty"`
+	PaymentID     *int      `json:"payment_id,omitempty"`
+	PaymentMethod string    `json:"payment_method,omitempty"`
+	Amount        int       `json:"amount"`
+	Balance       int       `json:"balance"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type ReceivableAccount struct {
+	CustomerID  int               `json:"customer_id"`
+	CreditLimit int               `json:"credit_limit"`
+	Balance     int               `json:"balance"`   // owed; negative when paid in advance
+	Available   int               `json:"available"` // credit left for kasbon sales
+	Entries     []ReceivableEntry `json:"entries"`   // oldest first
+}
+
+// ReceivableAging splits what a customer owes by the age of the kasbon
+// sales still unpaid; repayments settle the oldest sales first.
+type ReceivableAging struct {
+	CustomerID int    `json:"customer_id"`
+	Name       string `json:"name"`
+	MemberCode string `json:"member_code"`
+	Current    int    `json:"current"`      // 0-30 days
+	Days31To60 int    `json:"days_31_60"`   // 31-60 days
+	Over60     int    `json:"days_over_60"` // more than 60 days
+	Total      int    `json:"total"`
+}
+
+type AgingReport struct {
+	AsOf       string            `json:"as_of"`
+	Current    int               `json:"current"`
+	Days31To60 int               `json:"days_31_60"`
+	Over60     int               `json:"days_over_60"`
+	Total      int               `json:"total"`
+	Customers  []ReceivableAging `json:"customers"`
+}
@@ -0,0 +1,79 @@
+package models
+
+import "time"
+
+const (
+	ShiftStatusOpen   = "open"
+	ShiftStatusClosed = "closed"
+
+	CashMovementPayIn  = "pay_in"
+	CashMovementPayOut = "pay_out"
+
+	PaymentMethodCash     = "cash"
+	PaymentMethodCard     = "card"
+	PaymentMethodQRIS     = "qris"
+	PaymentMethodTransfer = "transfer"
+	PaymentMethodKasbon   = "kasbon" // pay later, charged to the customer's account
+)
+
+type Shift struct {
+	ID           int        `json:"id"`
+	CashierID    string     `json:"cashier_id"`
+	TerminalID   string     `json:"terminal_id"`
+	StoreID      string     `json:"store_id,omitempty"`
+	OpeningFloat int        `json:"opening_float"`
+	Status       string     `json:"status"`
+	OpenedAt     time.Time  `json:"opened_at"`
+	ClosedAt     *time.Time `json:"closed_at,omitempty"`
+	ClosedBy     string     `json:"closed_by,omitempty"`
+}
+
+type CashMovement struct {
+	ID        int       `json:"id"`
+	ShiftID   int       `json:"shift_id"`
+	Type      string    `json:"type"`
+	Amount    int       `json:"amount"`
+	Reason    string    `json:"reason"`
+	CashierID string    `json:"cashier_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type OpenShiftRequest struct {
+	OpeningFloat int `json:"opening_float"`
+}
+
+type CloseShiftRequest struct {
+	// Counted amount per payment method, e.g. {"cash": 750000, "card": 120000}
+	Counted map[string]int `json:"counted"`
+}
+
+// Sales and refunds recorded against a shift for a single payment method
+type TenderTotals struct {
+	PaymentMethod string
+	Sales         int
+	SalesCount    int
+	Refunds       int
+	RefundCount   int
+	Repayments    int // kasbon repayments taken in this method
+}
+
+type TenderReconciliation struct {
+	PaymentMethod string `json:"payment_method"`
+	Sales         int    `json:"sales"`
+	Refunds       int    `json:"refunds"`
+	Repayments    int    `json:"repayments"`
+	PayIns        int    `json:"pay_ins"`
+	PayOuts       int    `json:"pay_outs"`
+	Expected      int    `json:"expected"`
+	Counted       *int   `json:"counted,omitempty"`
+	Difference    *int   `json:"difference,omitempty"`
+}
+
+// X report is a running snapshot of an open shift, Z report is produced on close.
+type ShiftReport struct {
+	Type             string                 `json:"type"`
+	Shift            Shift                  `json:"shift"`
+	TotalTransaction int                    `json:"total_transaction"`
+	TotalRefund      int                    `json:"total_refund"`
+	Tenders          []TenderReconciliation `json:"tenders"`
+}
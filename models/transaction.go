@@ -2,29 +2,121 @@ package models
 
 import "time"
 
+const (
+	TransactionStatusCompleted = "completed"
+	TransactionStatusVoided    = "voided"
+	TransactionStatusRefunded  = "refunded"
+)
+
 type Transaction struct {
-	ID          int                 `json:"id"`
-	TotalAmount int                 `json:"total_amount"`
-	CreatedAt   time.Time           `json:"created_at"`
-	Details     []TransactionDetail `json:"details"`
+	ID              int                 `json:"id"`
+	TotalAmount     int                 `json:"total_amount"`
+	CashierID       string              `json:"cashier_id"`
+	TerminalID      string              `json:"terminal_id"`
+	StoreID         string              `json:"store_id,omitempty"`
+	PaymentMethod   string              `json:"payment_method"`
+	ShiftID         *int                `json:"shift_id,omitempty"`
+	CustomerID      *int                `json:"customer_id,omitempty"`
+	VoucherID       *int                `json:"voucher_id,omitempty"`
+	VoucherCode     string              `json:"voucher_code,omitempty"`
+	VoucherDiscount int                 `json:"voucher_discount,omitempty"` // taken off TotalAmount for the voucher
+	PointsRedeemed  int                 `json:"points_redeemed,omitempty"`
+	PointsDiscount  int                 `json:"points_discount,omitempty"` // taken off TotalAmount for redeemed points
+	PointsEarned    int                 `json:"points_earned,omitempty"`
+	Status          string              `json:"status"`
+	StatusChangedAt *time.Time          `json:"status_changed_at,omitempty"`
+	StatusChangedBy string              `json:"status_changed_by,omitempty"`
+	StatusReason    string              `json:"status_reason,omitempty"`
+	CreatedAt       time.Time           `json:"created_at"`
+	Details         []TransactionDetail `json:"details"`
 }
 
+// TransactionDetail is a sold line. Name, SKU, prices and cost are copied from
+// the product at checkout, so later catalog changes do not rewrite history.
+// UnitPrice and Cost are per unit of Quantity; a bundle's Cost is the cost
+// of one bundle.
 type TransactionDetail struct {
 	ID            int    `json:"id"`
 	TransactionID int    `json:"transaction_id"`
 	ProductID     int    `json:"product_id"`
-	ProductName   string `json:"product_name,omitempty"`
+	ProductName   string `json:"product_name"`
+	SKU           string `json:"sku"`
+	UnitPrice     int    `json:"unit_price"`
 	Quantity      int    `json:"quantity"`
+	Discount      int    `json:"discount"`
 	Subtotal      int    `json:"subtotal"`
+	Cost          int    `json:"cost"`
+	PriceRuleID   *int   `json:"price_rule_id,omitempty"` // time-based rule that set the price, if any
+
+	Modifiers  []TransactionDetailModifier  `json:"modifiers,omitempty"` // priced into UnitPrice
+	Note       string                       `json:"note,omitempty"`
+	Components []TransactionDetailComponent `json:"components,omitempty"` // contents of a bundle
+}
+
+// TransactionDetailModifier is a modifier as it was chosen on a line.
+type TransactionDetailModifier struct {
+	ModifierID int    `json:"modifier_id"`
+	GroupName  string `json:"group_name"`
+	Name       string `json:"name"`
+	PriceDelta int    `json:"price_delta"`
+}
+
+// TransactionDetailComponent is a product given out as part of a bundle line,
+// with its share of the line's subtotal. Quantity and Revenue cover the whole
+// line; Cost is per bundle, in the unit of the line's Cost, so the costs of a
+// line's components add up to it.
+type TransactionDetailComponent struct {
+	ProductID     int    `json:"product_id"`
+	ProductName   string `json:"product_name"`
+	ChoiceGroupID *int   `json:"choice_group_id,omitempty"`
+	Quantity      int    `json:"quantity"`
+	Revenue       int    `json:"revenue"`
+	Cost          int    `json:"cost"`
+}
+
+// Attribution identifies who rang up (or voided/refunded) a transaction and where.
+type Attribution struct {
+	CashierID  string `json:"cashier_id"`
+	TerminalID string `json:"terminal_id"`
+	StoreID    string `json:"store_id,omitempty"`
 }
 
 type CheckoutItem struct {
+	ProductID int              `json:"product_id"`
+	Quantity  int              `json:"quantity"`
+	Choices   []CheckoutChoice `json:"choices,omitempty"`   // picks for the choice groups of a bundle
+	Modifiers []int            `json:"modifiers,omitempty"` // modifier IDs, applied to every unit
+	Note      string           `json:"note,omitempty"`      // e.g. "less sugar"
+}
+
+// CheckoutChoice picks Quantity of a product, per bundle, for a choice group.
+type CheckoutChoice struct {
+	GroupID   int `json:"group_id"`
 	ProductID int `json:"product_id"`
 	Quantity  int `json:"quantity"`
 }
 
 type CheckoutRequest struct {
-	Items []CheckoutItem `json:"items"`
+	Items         []CheckoutItem `json:"items"`
+	PaymentMethod string         `json:"payment_method"`
+	CustomerID    *int           `json:"customer_id,omitempty"`   // member earning points on the sale
+	RedeemPoints  int            `json:"redeem_points,omitempty"` // points of the customer spent as a discount
+	VoucherCode   string         `json:"voucher_code,omitempty"`  // applied before points are redeemed
+
+	// Resolved by the service from the terminal's open shift
+	ShiftID *int `json:"-"`
+	// Cart being checked out, whose own reservation does not hold stock back from it;
+	// it is linked to the transaction in the same commit
+	CartID int `json:"-"`
+	// Store local time of the sale, set by the service for time-based price rules
+	SoldAt time.Time `json:"-"`
+	// Earn and redeem rates, set by the service from configuration
+	Loyalty LoyaltyProgram `json:"-"`
+}
+
+// Body for void and refund requests
+type StatusChangeRequest struct {
+	Reason string `json:"reason"`
 }
 
 // For report
@@ -38,3 +130,16 @@ type SalesSummary struct {
 	TotalTransaction int               `json:"total_transaction"`
 	BestSeller       ProductBestSeller `json:"best_seller"`
 }
+
+// Per cashier or per terminal figures; only one of CashierID/TerminalID is set.
+type SalesBreakdown struct {
+	CashierID        string `json:"cashier_id,omitempty"`
+	TerminalID       string `json:"terminal_id,omitempty"`
+	TotalTransaction int    `json:"total_transaction"`
+	TotalRevenue     int    `json:"total_revenue"`
+	AverageBasket    int    `json:"average_basket"`
+	TotalVoid        int    `json:"total_void"`
+	VoidAmount       int    `json:"void_amount"`
+	TotalRefund      int    `json:"total_refund"`
+	RefundAmount     int    `json:"refund_amount"`
+}
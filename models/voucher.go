@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+const (
+	VoucherPercentOff = "percent_off" // Value percent off the total, up to MaxDiscount when set
+	VoucherAmountOff  = "amount_off"  // Value Rupiah off the total
+)
+
+// Voucher is a code taking a discount off a checkout's total. MaxUses of 1
+// makes it single-use and nil unlimited; PerCustomerLimit caps the uses of
+// each customer and needs the checkout to name one. Uses is read-only.
+type Voucher struct {
+	ID               int        `json:"id"`
+	Code             string     `json:"code"` // generated when empty on create
+	Name             string     `json:"name"`
+	Type             string     `json:"type"`
+	Value            int        `json:"value"`
+	MaxDiscount      int        `json:"max_discount,omitempty"`
+	MinSpend         int        `json:"min_spend"`
+	MaxUses          *int       `json:"max_uses,omitempty"`
+	PerCustomerLimit *int       `json:"per_customer_limit,omitempty"`
+	ExpiresAt        *time.Time `json:"expires_at,omitempty"`
+	Uses             int        `json:"uses"`
+	CreatedAt        time.Time  `json:"created_at"`
+	DeletedAt        *time.Time `json:"deleted_at,omitempty"`
+}
+
+// Discount returns what the voucher takes off amount, never more than amount.
+func (v Voucher) Discount(amount int) int {
+	discount := 0
+	switch v.Type {
+	case VoucherPercentOff:
+		discount = amount * v.Value / 100
+		if v.MaxDiscount > 0 {
+			discount = min(discount, v.MaxDiscount)
+		}
+	case VoucherAmountOff:
+		discount = v.Value
+	}
+	return min(discount, amount)
+}
+
+// VoucherRedemption is a use of a voucher at checkout.
+type VoucherRedemption struct {
+	ID            int        `json:"id"`
+	TransactionID int        `json:"transaction_id"`
+	CustomerID    *int       `json:"customer_id,omitempty"`
+	Discount      int        `json:"discount"`
+	CreatedAt     time.Time  `json:"created_at"`
+	ReversedAt    *time.Time `json:"reversed_at,omitempty"` // the sale was voided or refunded
+}
@@ -0,0 +1,167 @@
+// Package openapi builds the OpenAPI 3.0 document from the routes the
+// router registers, so the published contract cannot drift from the code.
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+
+	// Problem is the body type of error responses, rendered as application/problem+json.
+	Problem any `json:"-"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+// PathItem maps lower-case HTTP methods to operations.
+type PathItem map[string]*Operation
+
+type Operation struct {
+	Summary     string               `json:"summary,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required,omitempty"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Route describes one registered route. Path parameters are taken from the
+// {wildcards} of the pattern; everything else is declared here.
+type Route struct {
+	Summary     string
+	Description string
+	Tag         string
+	Params      []Parameter
+
+	// Body is a value of the request body type, nil when the route takes none.
+	// OptionalBody marks bodies that may be omitted.
+	Body         any
+	OptionalBody bool
+
+	// Status is the success status, 200 when zero. Response is a value of the
+	// success body type, nil for responses without content.
+	Status      int
+	Response    any
+	ContentType string // success content type, application/json when empty
+
+	// Errors lists the problem statuses the route can answer with.
+	Errors []int
+}
+
+func New(info Info) *Document {
+	return &Document{
+		OpenAPI:    "3.0.3",
+		Info:       info,
+		Paths:      map[string]*PathItem{},
+		Components: Components{Schemas: map[string]*Schema{}},
+	}
+}
+
+func Query(name, description string) Parameter {
+	return Parameter{Name: name, In: "query", Description: description, Schema: &Schema{Type: "string"}}
+}
+
+func Header(name, description string) Parameter {
+	return Parameter{Name: name, In: "header", Description: description, Schema: &Schema{Type: "string"}}
+}
+
+// Add documents route under method and path, a ServeMux path pattern.
+func (d *Document) Add(method, path string, route Route) {
+	op := &Operation{
+		Summary:     route.Summary,
+		Description: route.Description,
+		Responses:   map[string]*Response{},
+	}
+	if route.Tag != "" {
+		op.Tags = []string{route.Tag}
+	}
+
+	path = strings.TrimSuffix(path, "{$}")
+	for _, segment := range strings.Split(path, "/") {
+		if name, ok := strings.CutPrefix(segment, "{"); ok {
+			name = strings.TrimSuffix(strings.TrimSuffix(name, "}"), "...")
+			op.Parameters = append(op.Parameters, Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "integer"}})
+		}
+	}
+	op.Parameters = append(op.Parameters, route.Params...)
+
+	if route.Body != nil {
+		op.RequestBody = &RequestBody{
+			Required: !route.OptionalBody,
+			Content:  map[string]MediaType{"application/json": {Schema: d.SchemaOf(route.Body)}},
+		}
+	}
+
+	status := route.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := &Response{Description: http.StatusText(status)}
+	if route.Response != nil {
+		contentType := route.ContentType
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		success.Content = map[string]MediaType{contentType: {Schema: d.SchemaOf(route.Response)}}
+	}
+	op.Responses[strconv.Itoa(status)] = success
+
+	for _, status := range route.Errors {
+		response := &Response{Description: http.StatusText(status)}
+		if d.Problem != nil {
+			response.Content = map[string]MediaType{"application/problem+json": {Schema: d.SchemaOf(d.Problem)}}
+		}
+		op.Responses[strconv.Itoa(status)] = response
+	}
+
+	item, ok := d.Paths[path]
+	if !ok {
+		item = &PathItem{}
+		d.Paths[path] = item
+	}
+	(*item)[strings.ToLower(method)] = op
+}
+
+// SchemaOf returns the schema of v's type. Named struct types are added to
+// the components and referenced.
+func (d *Document) SchemaOf(v any) *Schema {
+	return d.schemaOf(reflect.TypeOf(v))
+}
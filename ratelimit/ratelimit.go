@@ -0,0 +1,133 @@
+// Package ratelimit provides token-bucket rate limiting behind the Limiter
+// interface, so the in-process store can later be swapped for a shared one.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit allows Requests per Period on average, with bursts of up to Burst
+// requests (Requests when zero). The zero Limit is unlimited.
+type Limit struct {
+	Requests int
+	Period   time.Duration
+	Burst    int
+}
+
+func (l Limit) Unlimited() bool {
+	return l.Requests <= 0 || l.Period <= 0
+}
+
+func (l Limit) burst() int {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+	return l.Requests
+}
+
+// String renders the limit as a RateLimit-Policy value, e.g. "10;w=60".
+func (l Limit) String() string {
+	return fmt.Sprintf("%d;w=%d", l.Requests, int(l.Period.Seconds()))
+}
+
+// ParseLimit parses "requests/period[/burst]", e.g. "10/1m" or "100/1m/20".
+// An empty value or "0" means unlimited.
+func ParseLimit(value string) (Limit, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || value == "0" {
+		return Limit{}, nil
+	}
+
+	parts := strings.Split(value, "/")
+	if len(parts) < 2 || len(parts) > 3 {
+		return Limit{}, fmt.Errorf("rate limit %q: want requests/period[/burst]", value)
+	}
+
+	var limit Limit
+	var err error
+	if limit.Requests, err = strconv.Atoi(parts[0]); err != nil || limit.Requests < 0 {
+		return Limit{}, fmt.Errorf("rate limit %q: invalid request count", value)
+	}
+	if limit.Period, err = time.ParseDuration(parts[1]); err != nil || limit.Period <= 0 {
+		return Limit{}, fmt.Errorf("rate limit %q: invalid period", value)
+	}
+	if len(parts) == 3 {
+		if limit.Burst, err = strconv.Atoi(parts[2]); err != nil || limit.Burst < 0 {
+			return Limit{}, fmt.Errorf("rate limit %q: invalid burst", value)
+		}
+	}
+	return limit, nil
+}
+
+// Policy holds the limits of every route in one place. Routes not listed
+// get Default.
+type Policy struct {
+	Default Limit
+	Routes  map[string]Limit
+}
+
+func (p Policy) For(route string) Limit {
+	if limit, ok := p.Routes[route]; ok {
+		return limit
+	}
+	return p.Default
+}
+
+// ParseRoutes parses "route=limit" entries separated by semicolons, e.g.
+// "GET /report/sales-summary=10/1m;GET /report/cashiers=10/1m".
+func ParseRoutes(value string) (map[string]Limit, error) {
+	routes := map[string]Limit{}
+	for _, entry := range strings.Split(value, ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+
+		route, spec, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("route rate limit %q: want route=limit", entry)
+		}
+		limit, err := ParseLimit(spec)
+		if err != nil {
+			return nil, err
+		}
+		routes[strings.TrimSpace(route)] = limit
+	}
+	return routes, nil
+}
+
+// ParseProxies parses trusted proxy addresses, each an IP or a CIDR range,
+// e.g. "10.0.0.1" or "172.16.0.0/12".
+func ParseProxies(list []string) ([]netip.Prefix, error) {
+	proxies := make([]netip.Prefix, 0, len(list))
+	for _, entry := range list {
+		if prefix, err := netip.ParsePrefix(entry); err == nil {
+			proxies = append(proxies, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(entry)
+		if err != nil {
+			return nil, fmt.Errorf("trusted proxy %q: want an IP or CIDR range", entry)
+		}
+		addr = addr.Unmap()
+		proxies = append(proxies, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return proxies, nil
+}
+
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration // until the bucket is full again
+	RetryAfter time.Duration // until the next request is allowed; zero when Allowed
+}
+
+type Limiter interface {
+	// Allow takes one token from the bucket identified by key.
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+}
@@ -0,0 +1,115 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"kasir-api/audit"
+	"kasir-api/logging"
+	"kasir-api/models"
+	"strconv"
+)
+
+type AuditRepository struct {
+	db *sql.DB
+}
+
+func NewAuditRepository(db *sql.DB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+// recordAudit writes an audit entry inside tx, so it is committed or rolled
+// back together with the change it describes. before is nil for creates and
+// after is nil for deletes.
+func recordAudit(ctx context.Context, tx *sql.Tx, entity string, entityID int, action string, before, after audit.Record) error {
+	var beforeJSON, afterJSON []byte
+	var err error
+	if before != nil {
+		if beforeJSON, err = json.Marshal(before); err != nil {
+			return err
+		}
+	}
+	if after != nil {
+		if afterJSON, err = json.Marshal(after); err != nil {
+			return err
+		}
+	}
+	changes, err := json.Marshal(audit.Diff(before, after))
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO audit_log (entity, entity_id, action, actor, request_id, before, after, changes)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		entity, entityID, action, audit.Actor(ctx), logging.RequestID(ctx), nullJSON(beforeJSON), nullJSON(afterJSON), changes)
+	return err
+}
+
+// nullJSON stores an absent side of the change as SQL NULL rather than "null".
+func nullJSON(b []byte) interface{} {
+	if b == nil {
+		return nil
+	}
+	return string(b)
+}
+
+func (repo *AuditRepository) List(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error) {
+	query := `
+		SELECT id, entity, entity_id, action, actor, request_id,
+		       COALESCE(before::text, ''), COALESCE(after::text, ''), COALESCE(changes::text, ''), created_at
+		FROM audit_log
+		WHERE 1 = 1`
+	args := []interface{}{}
+
+	if filter.Entity != "" {
+		args = append(args, filter.Entity)
+		query += " AND entity = $" + strconv.Itoa(len(args))
+	}
+	if filter.EntityID != 0 {
+		args = append(args, filter.EntityID)
+		query += " AND entity_id = $" + strconv.Itoa(len(args))
+	}
+	if filter.Actor != "" {
+		args = append(args, filter.Actor)
+		query += " AND actor = $" + strconv.Itoa(len(args))
+	}
+	if filter.Start != nil {
+		args = append(args, *filter.Start)
+		query += " AND created_at >= $" + strconv.Itoa(len(args))
+	}
+	if filter.End != nil {
+		args = append(args, *filter.End)
+		query += " AND created_at <= $" + strconv.Itoa(len(args))
+	}
+	args = append(args, filter.Limit)
+	query += " ORDER BY id DESC LIMIT $" + strconv.Itoa(len(args))
+
+	rows, err := repo.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]models.AuditEntry, 0)
+	for rows.Next() {
+		var e models.AuditEntry
+		var before, after, changes string
+		err := rows.Scan(&e.ID, &e.Entity, &e.EntityID, &e.Action, &e.Actor, &e.RequestID,
+			&before, &after, &changes, &e.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		e.Before, e.After, e.Changes = rawJSON(before), rawJSON(after), rawJSON(changes)
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
+
+func rawJSON(s string) json.RawMessage {
+	if s == "" {
+		return nil
+	}
+	return json.RawMessage(s)
+}
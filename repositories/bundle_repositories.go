@@ -0,0 +1,377 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"kasir-api/apperror"
+	"kasir-api/audit"
+	"kasir-api/models"
+)
+
+type BundleRepository struct {
+	db *sql.DB
+}
+
+func NewBundleRepository(db *sql.DB) *BundleRepository {
+	return &BundleRepository{db: db}
+}
+
+// availableStock is the SQL for the stock of the product row aliased as
+// alias. A bundle has no stock of its own: it is the number of bundles its
+// components and choice groups can still make up, and deleted components
+// count as out of stock.
+func availableStock(alias string) string {
+	return `CASE WHEN ` + alias + `.is_bundle THEN COALESCE(LEAST(
+			(SELECT MIN(CASE WHEN cp.deleted_at IS NULL THEN cp.stock ELSE 0 END / bc.quantity)
+			 FROM bundle_components bc
+			 JOIN products cp ON cp.id = bc.product_id
+			 WHERE bc.bundle_id = ` + alias + `.id),
+			(SELECT MIN(g.available) FROM (
+				SELECT COALESCE(SUM(cp.stock), 0) / bg.quantity AS available
+				FROM bundle_choice_groups bg
+				LEFT JOIN products cp ON cp.category_id = bg.category_id AND cp.deleted_at IS NULL AND NOT cp.is_bundle
+				WHERE bg.bundle_id = ` + alias + `.id
+				GROUP BY bg.id, bg.quantity) g)
+		), 0) ELSE ` + alias + `.stock END`
+}
+
+// Get returns the contents of a bundle product.
+func (repo *BundleRepository) Get(ctx context.Context, productID int) (*models.Bundle, error) {
+	var isBundle bool
+	bundle := &models.Bundle{ProductID: productID}
+	err := repo.db.QueryRowContext(ctx,
+		"SELECT is_bundle, "+availableStock("products")+" FROM products WHERE id = $1 AND deleted_at IS NULL", productID).
+		Scan(&isBundle, &bundle.Available)
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("product not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !isBundle {
+		return nil, apperror.NotFound("product is not a bundle")
+	}
+
+	bundle.Components, bundle.ChoiceGroups, err = loadBundle(ctx, repo.db, productID)
+	if err != nil {
+		return nil, err
+	}
+	return bundle, nil
+}
+
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+func loadBundle(ctx context.Context, q queryer, productID int) ([]models.BundleComponent, []models.BundleChoiceGroup, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT bc.product_id, p.name, bc.quantity
+		FROM bundle_components bc
+		JOIN products p ON p.id = bc.product_id
+		WHERE bc.bundle_id = $1
+		ORDER BY bc.id`, productID)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	components := make([]models.BundleComponent, 0)
+	for rows.Next() {
+		var c models.BundleComponent
+		if err := rows.Scan(&c.ProductID, &c.ProductName, &c.Quantity); err != nil {
+			return nil, nil, err
+		}
+		components = append(components, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	rows.Close()
+
+	groups, err := loadChoiceGroups(ctx, q, productID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return components, groups, nil
+}
+
+func loadChoiceGroups(ctx context.Context, q queryer, productID int) ([]models.BundleChoiceGroup, error) {
+	rows, err := q.QueryContext(ctx,
+		"SELECT id, name, category_id, quantity FROM bundle_choice_groups WHERE bundle_id = $1 ORDER BY id", productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	groups := make([]models.BundleChoiceGroup, 0)
+	for rows.Next() {
+		var g models.BundleChoiceGroup
+		if err := rows.Scan(&g.ID, &g.Name, &g.CategoryID, &g.Quantity); err != nil {
+			return nil, err
+		}
+		groups = append(groups, g)
+	}
+	return groups, rows.Err()
+}
+
+// Set replaces the contents of the product and makes it a bundle. A product
+// that is a component of another bundle cannot become one.
+func (repo *BundleRepository) Set(ctx context.Context, productID int, req models.SetBundleRequest) error {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	product, err := lockProduct(ctx, tx, productID, false)
+	if err != nil {
+		return err
+	}
+
+	// a component turned into a bundle would nest bundles, whose own stock is meaningless
+	var parent string
+	err = tx.QueryRowContext(ctx, `
+		SELECT p.name FROM bundle_components bc
+		JOIN products p ON p.id = bc.bundle_id
+		WHERE bc.product_id = $1
+		ORDER BY bc.bundle_id
+		LIMIT 1`, productID).Scan(&parent)
+	if err == nil {
+		return apperror.Conflict("product is a component of bundle %s and cannot be a bundle itself", parent)
+	}
+	if err != sql.ErrNoRows {
+		return err
+	}
+
+	var before audit.Record
+	if product.IsBundle {
+		components, groups, err := loadBundle(ctx, tx, productID)
+		if err != nil {
+			return err
+		}
+		before = bundleRecord(components, groups)
+	}
+
+	if err := clearBundle(ctx, tx, productID); err != nil {
+		return err
+	}
+	for _, c := range req.Components {
+		_, err := tx.ExecContext(ctx, "INSERT INTO bundle_components (bundle_id, product_id, quantity) VALUES ($1, $2, $3)",
+			productID, c.ProductID, c.Quantity)
+		if err != nil {
+			return mapDBError(err, "component product does not exist")
+		}
+	}
+	for _, g := range req.ChoiceGroups {
+		_, err := tx.ExecContext(ctx, "INSERT INTO bundle_choice_groups (bundle_id, name, category_id, quantity) VALUES ($1, $2, $3, $4)",
+			productID, g.Name, g.CategoryID, g.Quantity)
+		if err != nil {
+			return mapDBError(err, "choice group category does not exist")
+		}
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE products SET is_bundle = TRUE WHERE id = $1", productID); err != nil {
+		return err
+	}
+
+	after := bundleRecord(req.Components, req.ChoiceGroups)
+	if err := recordAudit(ctx, tx, models.AuditEntityProduct, productID, models.AuditActionUpdate, before, after); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Remove turns a bundle back into a regular product with its own stock.
+func (repo *BundleRepository) Remove(ctx context.Context, productID int) error {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	product, err := lockProduct(ctx, tx, productID, false)
+	if err != nil {
+		return err
+	}
+	if !product.IsBundle {
+		return apperror.NotFound("product is not a bundle")
+	}
+
+	components, groups, err := loadBundle(ctx, tx, productID)
+	if err != nil {
+		return err
+	}
+	if err := clearBundle(ctx, tx, productID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE products SET is_bundle = FALSE WHERE id = $1", productID); err != nil {
+		return err
+	}
+
+	err = recordAudit(ctx, tx, models.AuditEntityProduct, productID, models.AuditActionUpdate, bundleRecord(components, groups), nil)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func clearBundle(ctx context.Context, tx *sql.Tx, productID int) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM bundle_components WHERE bundle_id = $1", productID); err != nil {
+		return err
+	}
+	_, err := tx.ExecContext(ctx, "DELETE FROM bundle_choice_groups WHERE bundle_id = $1", productID)
+	return err
+}
+
+func bundleRecord(components []models.BundleComponent, groups []models.BundleChoiceGroup) audit.Record {
+	c := make([]audit.Record, 0, len(components))
+	for _, component := range components {
+		c = append(c, audit.Record{"product_id": component.ProductID, "quantity": component.Quantity})
+	}
+	g := make([]audit.Record, 0, len(groups))
+	for _, group := range groups {
+		g = append(g, audit.Record{"name": group.Name, "category_id": group.CategoryID, "quantity": group.Quantity})
+	}
+	return audit.Record{"bundle_components": c, "bundle_choice_groups": g}
+}
+
+// bundlePart is a product going out with one bundle.
+type bundlePart struct {
+	productID     int
+	name          string
+	choiceGroupID *int
+	quantity      int
+	price         int
+	cost          int
+}
+
+// bundleParts resolves what one bundle is made of: its fixed components plus
+// the products picked for each choice group.
+func bundleParts(ctx context.Context, tx *sql.Tx, bundleID int, choices []models.CheckoutChoice) ([]bundlePart, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT bc.product_id, p.name, bc.quantity, `+effectivePrice("p")+`, p.cost, p.deleted_at IS NOT NULL, p.is_bundle
+		FROM bundle_components bc
+		JOIN products p ON p.id = bc.product_id
+		WHERE bc.bundle_id = $1
+		ORDER BY bc.id`, bundleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	parts := make([]bundlePart, 0)
+	for rows.Next() {
+		var part bundlePart
+		var deleted, isBundle bool
+		if err := rows.Scan(&part.productID, &part.name, &part.quantity, &part.price, &part.cost, &deleted, &isBundle); err != nil {
+			return nil, err
+		}
+		if deleted {
+			return nil, apperror.Conflict("product %s of the bundle is no longer sold", part.name)
+		}
+		if isBundle {
+			return nil, apperror.Conflict("product %s of the bundle is a bundle itself", part.name)
+		}
+		parts = append(parts, part)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	groups, err := loadChoiceGroups(ctx, tx, bundleID)
+	if err != nil {
+		return nil, err
+	}
+	chosen := make(map[int]int, len(groups))
+	for _, choice := range choices {
+		var group *models.BundleChoiceGroup
+		for i := range groups {
+			if groups[i].ID == choice.GroupID {
+				group = &groups[i]
+			}
+		}
+		if group == nil {
+			return nil, apperror.Validation("choices", fmt.Sprintf("choice group %d is not part of the bundle", choice.GroupID))
+		}
+
+		part := bundlePart{productID: choice.ProductID, choiceGroupID: &group.ID, quantity: choice.Quantity}
+		var categoryID int
+		var isBundle bool
+		err := tx.QueryRowContext(ctx, `
+			SELECT name, `+effectivePrice("products")+`, cost, COALESCE(category_id, 0), is_bundle
+			FROM products
+			WHERE id = $1 AND deleted_at IS NULL`, choice.ProductID).
+			Scan(&part.name, &part.price, &part.cost, &categoryID, &isBundle)
+		if err == sql.ErrNoRows {
+			return nil, apperror.NotFound("product id %d not found", choice.ProductID)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if categoryID != group.CategoryID || isBundle {
+			return nil, apperror.Validation("choices", fmt.Sprintf("product %s cannot be chosen for %s", part.name, group.Name))
+		}
+
+		chosen[group.ID] += choice.Quantity
+		parts = append(parts, part)
+	}
+	for _, group := range groups {
+		if chosen[group.ID] != group.Quantity {
+			return nil, apperror.Validation("choices", fmt.Sprintf("choose %d for %s", group.Quantity, group.Name))
+		}
+	}
+
+	return parts, nil
+}
+
+// allocateRevenue splits a bundle line's subtotal across its parts in
+// proportion to their own prices; rounding leftovers go to the part with the
+// largest share so the parts add up to the subtotal.
+func allocateRevenue(subtotal int, parts []bundlePart) []int {
+	weights := make([]int, len(parts))
+	total := 0
+	for i, part := range parts {
+		weights[i] = part.price * part.quantity
+		total += weights[i]
+	}
+	if total == 0 {
+		for i, part := range parts {
+			weights[i] = part.quantity
+			total += part.quantity
+		}
+	}
+
+	shares := make([]int, len(parts))
+	if total == 0 {
+		return shares
+	}
+	allocated, largest := 0, 0
+	for i := range parts {
+		shares[i] = subtotal * weights[i] / total
+		allocated += shares[i]
+		if weights[i] > weights[largest] {
+			largest = i
+		}
+	}
+	shares[largest] += subtotal - allocated
+	return shares
+}
+
+// deductStock takes quantity of a product out of stock, refusing to go below
+// what reserving carts other than cartID hold of it.
+func deductStock(ctx context.Context, tx *sql.Tx, productID, quantity int, name string, cartID int) error {
+	result, err := tx.ExecContext(ctx, "UPDATE products SET stock = stock - $1 WHERE id = $2 AND stock - $1 >= "+reservedQuantity("$2", "$3"),
+		quantity, productID, cartID)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return apperror.InsufficientStock("insufficient stock for product %s", name)
+	}
+	return nil
+}
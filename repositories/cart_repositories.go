@@ -0,0 +1,257 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"kasir-api/apperror"
+	"kasir-api/models"
+	"log/slog"
+	"strconv"
+	"time"
+)
+
+type CartRepository struct {
+	db *sql.DB
+}
+
+func NewCartRepository(db *sql.DB) *CartRepository {
+	return &CartRepository{db: db}
+}
+
+const cartColumns = `id, status, cashier_id, terminal_id, store_id, note, reserve_stock,
+	reserved_until, transaction_id, created_at, updated_at`
+
+func scanCart(row interface{ Scan(...interface{}) error }) (*models.Cart, error) {
+	var c models.Cart
+	err := row.Scan(&c.ID, &c.Status, &c.CashierID, &c.TerminalID, &c.StoreID, &c.Note, &c.ReserveStock,
+		&c.ReservedUntil, &c.TransactionID, &c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	c.Items = make([]models.CartItem, 0)
+	return &c, nil
+}
+
+func (repo *CartRepository) Create(ctx context.Context, cart *models.Cart) error {
+	query := `
+		INSERT INTO carts (cashier_id, terminal_id, store_id, note, reserve_stock, reserved_until)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING ` + cartColumns
+	created, err := scanCart(repo.db.QueryRowContext(ctx, query, cart.CashierID, cart.TerminalID, cart.StoreID,
+		cart.Note, cart.ReserveStock, cart.ReservedUntil))
+	if err != nil {
+		return err
+	}
+
+	*cart = *created
+	return nil
+}
+
+// GetByID returns the cart with its lines priced as checkout would price them
+// at at (store local time): the current product price less the best price
+// rule. Lines a checkout would refuse are flagged and left out of the total.
+func (repo *CartRepository) GetByID(ctx context.Context, id int, at time.Time) (*models.Cart, error) {
+	cart, err := scanCart(repo.db.QueryRowContext(ctx, "SELECT "+cartColumns+" FROM carts WHERE id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("cart not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// price rules are looked up the way checkout does, inside a transaction
+	tx, err := repo.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		SELECT ci.product_id, p.name, ` + effectivePrice("p") + `, ci.quantity, ` + availableStock("p") + `,
+		       COALESCE(p.category_id, 0), p.deleted_at IS NOT NULL
+		FROM cart_items ci
+		JOIN products p ON ci.product_id = p.id
+		WHERE ci.cart_id = $1
+		ORDER BY p.name`
+	rows, err := tx.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var categories []int
+	var deleted []bool
+	for rows.Next() {
+		var item models.CartItem
+		var categoryID int
+		var isDeleted bool
+		err := rows.Scan(&item.ProductID, &item.ProductName, &item.Price, &item.Quantity, &item.Stock, &categoryID, &isDeleted)
+		if err != nil {
+			return nil, err
+		}
+		cart.Items = append(cart.Items, item)
+		categories = append(categories, categoryID)
+		deleted = append(deleted, isDeleted)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	for i := range cart.Items {
+		item := &cart.Items[i]
+		switch {
+		case deleted[i]:
+			item.Unavailable = models.CartItemDeleted
+		case item.Stock < item.Quantity:
+			item.Unavailable = models.CartItemOutOfStock
+		}
+
+		rule, err := bestPriceRule(ctx, tx, item.ProductID, categories[i], item.Price, at)
+		if err != nil {
+			return nil, err
+		}
+		if rule != nil {
+			item.Discount = (item.Price - rule.Apply(item.Price)) * item.Quantity
+			item.PriceRuleID = &rule.ID
+		}
+		item.Subtotal = item.Price*item.Quantity - item.Discount
+		if item.Unavailable == "" {
+			cart.TotalAmount += item.Subtotal
+		}
+	}
+
+	return cart, nil
+}
+
+// GetAll lists carts without their lines, optionally filtered by status and terminal.
+func (repo *CartRepository) GetAll(ctx context.Context, status, terminalID string) ([]models.Cart, error) {
+	query := "SELECT " + cartColumns + " FROM carts WHERE 1=1"
+	args := []interface{}{}
+
+	if status != "" {
+		args = append(args, status)
+		query += " AND status = $1"
+	}
+	if terminalID != "" {
+		args = append(args, terminalID)
+		query += " AND terminal_id = $" + strconv.Itoa(len(args))
+	}
+	query += " ORDER BY updated_at DESC"
+
+	rows, err := repo.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	carts := make([]models.Cart, 0)
+	for rows.Next() {
+		cart, err := scanCart(rows)
+		if err != nil {
+			return nil, err
+		}
+		carts = append(carts, *cart)
+	}
+
+	return carts, rows.Err()
+}
+
+// SetItemQuantity sets the quantity of a product in the cart; zero removes the line.
+func (repo *CartRepository) SetItemQuantity(ctx context.Context, cartID, productID, quantity int) error {
+	if quantity == 0 {
+		result, err := repo.db.ExecContext(ctx, "DELETE FROM cart_items WHERE cart_id = $1 AND product_id = $2", cartID, productID)
+		if err != nil {
+			return err
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return apperror.NotFound("product is not in the cart")
+		}
+		return nil
+	}
+
+	query := `
+		INSERT INTO cart_items (cart_id, product_id, quantity) VALUES ($1, $2, $3)
+		ON CONFLICT (cart_id, product_id) DO UPDATE SET quantity = EXCLUDED.quantity`
+	_, err := repo.db.ExecContext(ctx, query, cartID, productID, quantity)
+	return err
+}
+
+// reservedQuantity is the SQL for what carts other than cart hold of product
+// under an unexpired reservation, including as a fixed component of a bundle
+// in the cart.
+func reservedQuantity(product, cart string) string {
+	return `(SELECT COALESCE(SUM(ci.quantity * COALESCE(bc.quantity, 1)), 0)
+		FROM cart_items ci
+		JOIN carts c ON ci.cart_id = c.id
+		LEFT JOIN bundle_components bc ON bc.bundle_id = ci.product_id AND bc.product_id = ` + product + `
+		WHERE (ci.product_id = ` + product + ` OR bc.product_id IS NOT NULL) AND c.id <> ` + cart + `
+		  AND c.reserve_stock AND c.status IN ('active', 'held') AND c.reserved_until > NOW())`
+}
+
+// GetReservedQuantity sums what other carts with an unexpired reservation hold of a product.
+func (repo *CartRepository) GetReservedQuantity(ctx context.Context, productID, excludeCartID int) (int, error) {
+	var reserved int
+	err := repo.db.QueryRowContext(ctx, "SELECT "+reservedQuantity("$1", "$2"), productID, excludeCartID).Scan(&reserved)
+	return reserved, err
+}
+
+// RequiredChoice returns the name of a bundle choice group or required
+// modifier group of the product, or "" when it has none. Cart lines hold no
+// picks, so such products cannot be sold through a cart.
+func (repo *CartRepository) RequiredChoice(ctx context.Context, productID int) (string, error) {
+	var name string
+	err := repo.db.QueryRowContext(ctx, `
+		SELECT name FROM (
+			SELECT bg.name, 1 AS kind
+			FROM bundle_choice_groups bg
+			JOIN products p ON p.id = bg.bundle_id AND p.is_bundle
+			WHERE bg.bundle_id = $1
+			UNION ALL
+			SELECT g.name, 2
+			FROM modifier_groups g
+			WHERE g.deleted_at IS NULL AND g.min_select > 0
+			  AND (g.product_id = $1 OR g.category_id = (SELECT category_id FROM products WHERE id = $1))
+		) groups
+		ORDER BY kind, name
+		LIMIT 1`, productID).Scan(&name)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return name, err
+}
+
+// Touch bumps updated_at and, for reserving carts, extends the reservation.
+func (repo *CartRepository) Touch(ctx context.Context, id int, reservedUntil *time.Time) error {
+	_, err := repo.db.ExecContext(ctx, `
+		UPDATE carts
+		SET updated_at = NOW(), reserved_until = CASE WHEN reserve_stock THEN $1 ELSE reserved_until END
+		WHERE id = $2`, reservedUntil, id)
+	return err
+}
+
+// UpdateStatus moves the cart to status only if it is currently in one of from.
+func (repo *CartRepository) UpdateStatus(ctx context.Context, id int, from []string, status string) error {
+	query := `
+		UPDATE carts SET status = $1, updated_at = NOW()
+		WHERE id = $2 AND status = ANY($3)`
+	result, err := repo.db.ExecContext(ctx, query, status, id, from)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		slog.DebugContext(ctx, "cart status change refused", slog.Int("cart_id", id), slog.String("status", status))
+		return apperror.Conflict("cart not found or not in a state that allows this action")
+	}
+
+	return nil
+}
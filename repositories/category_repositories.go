@@ -1,8 +1,10 @@
 package repositories
 
 import (
+	"context"
 	"database/sql"
-	"errors"
+	"kasir-api/apperror"
+	"kasir-api/audit"
 	"kasir-api/models"
 )
 
@@ -14,16 +16,20 @@ func NewCategoryRepository(db *sql.DB) *CategoryRepository {
 	return &CategoryRepository{db: db}
 }
 
-func (repo *CategoryRepository) GetAll(name string) ([]models.Category, error) {
-	query := "SELECT id, name, description FROM categories"
+// GetAll lists categories; soft-deleted ones only when includeDeleted is set.
+func (repo *CategoryRepository) GetAll(ctx context.Context, name string, includeDeleted bool) ([]models.Category, error) {
+	query := "SELECT id, name, description, deleted_at FROM categories WHERE 1 = 1"
 	args := []interface{}{}
 
+	if !includeDeleted {
+		query += " AND deleted_at IS NULL"
+	}
 	if name != "" {
-		query += " WHERE name ILIKE $1"
+		query += " AND name ILIKE $1"
 		args = append(args, "%"+name+"%")
 	}
 
-	rows, err := repo.db.Query(query, args...)
+	rows, err := repo.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -32,77 +38,171 @@ func (repo *CategoryRepository) GetAll(name string) ([]models.Category, error) {
 	categories := make([]models.Category, 0)
 	for rows.Next() {
 		var c models.Category
-		err := rows.Scan(&c.ID, &c.Name, &c.Description)
+		err := rows.Scan(&c.ID, &c.Name, &c.Description, &c.DeletedAt)
 		if err != nil {
 			return nil, err
 		}
+		c.Active = c.DeletedAt == nil
 		categories = append(categories, c)
 	}
 
-	return categories, nil
+	return categories, rows.Err()
 }
 
-func (repo *CategoryRepository) Create(category *models.Category) error {
+func (repo *CategoryRepository) Create(ctx context.Context, category *models.Category) error {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	query := "INSERT INTO categories (name, description) VALUES ($1, $2) RETURNING id"
-	err := repo.db.QueryRow(query, category.Name, category.Description).Scan(&category.ID)
-	return err
+	err = tx.QueryRowContext(ctx, query, category.Name, category.Description).Scan(&category.ID)
+	if err != nil {
+		return err
+	}
+
+	err = recordAudit(ctx, tx, models.AuditEntityCategory, category.ID, models.AuditActionCreate, nil, categoryRecord(category))
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
-func (repo *CategoryRepository) GetByID(id int) (*models.Category, error) {
-	query := "SELECT id, name, description FROM categories WHERE id = $1"
+// GetByID returns the category; a soft-deleted one is not found unless includeDeleted is set.
+func (repo *CategoryRepository) GetByID(ctx context.Context, id int, includeDeleted bool) (*models.Category, error) {
+	query := "SELECT id, name, description, deleted_at FROM categories WHERE id = $1"
+	if !includeDeleted {
+		query += " AND deleted_at IS NULL"
+	}
 
 	var c models.Category
-	err := repo.db.QueryRow(query, id).Scan(&c.ID, &c.Name, &c.Description)
+	err := repo.db.QueryRowContext(ctx, query, id).Scan(&c.ID, &c.Name, &c.Description, &c.DeletedAt)
 	if err == sql.ErrNoRows {
-		return nil, errors.New("category not found")
+		return nil, apperror.NotFound("category not found")
 	}
 	if err != nil {
 		return nil, err
 	}
 
+	c.Active = c.DeletedAt == nil
 	return &c, nil
 }
 
-func (repo *CategoryRepository) Update(category *models.Category) error {
+func (repo *CategoryRepository) Update(ctx context.Context, category *models.Category) error {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := lockCategory(ctx, tx, category.ID, false)
+	if err != nil {
+		return err
+	}
+
 	query := "UPDATE categories SET name = $1, description = $2 WHERE id = $3"
-	result, err := repo.db.Exec(query, category.Name, category.Description, category.ID)
+	_, err = tx.ExecContext(ctx, query, category.Name, category.Description, category.ID)
+	if err != nil {
+		return err
+	}
+
+	err = recordAudit(ctx, tx, models.AuditEntityCategory, category.ID, models.AuditActionUpdate, categoryRecord(before), categoryRecord(category))
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Delete soft-deletes the category. It is refused while products that are
+// not deleted still use it.
+func (repo *CategoryRepository) Delete(ctx context.Context, id int) error {
+	tx, err := repo.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
-	rows, err := result.RowsAffected()
+	before, err := lockCategory(ctx, tx, id, false)
 	if err != nil {
 		return err
 	}
 
-	if rows == 0 {
-		return errors.New("category not found")
+	var inUse bool
+	err = tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM products WHERE category_id = $1 AND deleted_at IS NULL)", id).Scan(&inUse)
+	if err != nil {
+		return err
+	}
+	if inUse {
+		return apperror.Conflict("category is still used by products")
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE categories SET deleted_at = NOW() WHERE id = $1", id)
+	if err != nil {
+		return err
 	}
 
-	return nil
+	err = recordAudit(ctx, tx, models.AuditEntityCategory, id, models.AuditActionDelete, categoryRecord(before), nil)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
-func (repo *CategoryRepository) Delete(id int) error {
-	query := "DELETE FROM categories WHERE id = $1"
-	result, err := repo.db.Exec(query, id)
+// Restore undoes a soft delete.
+func (repo *CategoryRepository) Restore(ctx context.Context, id int) error {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	category, err := lockCategory(ctx, tx, id, true)
+	if err != nil {
+		return err
+	}
+	if category.DeletedAt == nil {
+		return apperror.Conflict("category is not deleted")
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE categories SET deleted_at = NULL WHERE id = $1", id)
 	if err != nil {
 		return err
 	}
-	rows, err := result.RowsAffected()
+
+	err = recordAudit(ctx, tx, models.AuditEntityCategory, id, models.AuditActionRestore, nil, categoryRecord(category))
 	if err != nil {
 		return err
 	}
+	return tx.Commit()
+}
 
-	if rows == 0 {
-		return errors.New("category not found")
+// lockCategory reads the category row for a change within tx. Soft-deleted
+// categories are not found unless includeDeleted is set.
+func lockCategory(ctx context.Context, tx *sql.Tx, id int, includeDeleted bool) (*models.Category, error) {
+	query := "SELECT id, name, description, deleted_at FROM categories WHERE id = $1"
+	if !includeDeleted {
+		query += " AND deleted_at IS NULL"
 	}
 
-	return nil
+	var c models.Category
+	err := tx.QueryRowContext(ctx, query+" FOR UPDATE", id).Scan(&c.ID, &c.Name, &c.Description, &c.DeletedAt)
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("category not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func categoryRecord(c *models.Category) audit.Record {
+	return audit.Record{"name": c.Name, "description": c.Description}
 }
 
-func (repo *CategoryRepository) Exists(name string, description string) (bool, error) {
+func (repo *CategoryRepository) Exists(ctx context.Context, name string, description string) (bool, error) {
 	var exists bool
-	query := "SELECT EXISTS(SELECT 1 FROM categories WHERE name = $1 AND description = $2)"
-	err := repo.db.QueryRow(query, name, description).Scan(&exists)
+	query := "SELECT EXISTS(SELECT 1 FROM categories WHERE name = $1 AND description = $2 AND deleted_at IS NULL)"
+	err := repo.db.QueryRowContext(ctx, query, name, description).Scan(&exists)
 	return exists, err
 }
@@ -0,0 +1,271 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"kasir-api/apperror"
+	"kasir-api/audit"
+	"kasir-api/models"
+)
+
+type CustomerRepository struct {
+	db *sql.DB
+}
+
+func NewCustomerRepository(db *sql.DB) *CustomerRepository {
+	return &CustomerRepository{db: db}
+}
+
+var customerColumns = `c.id, c.name, c.phone, c.email, c.member_code,
+	COALESCE((SELECT SUM(l.points) FROM loyalty_ledger l WHERE l.customer_id = c.id), 0),
+	c.credit_limit, ` + receivableBalance("c") + `, c.created_at, c.deleted_at`
+
+func scanCustomer(row interface{ Scan(...interface{}) error }) (*models.Customer, error) {
+	var c models.Customer
+	err := row.Scan(&c.ID, &c.Name, &c.Phone, &c.Email, &c.MemberCode, &c.Points, &c.CreditLimit, &c.Receivable,
+		&c.CreatedAt, &c.DeletedAt)
+	if err != nil {
+		return nil, err
+	}
+	c.Active = c.DeletedAt == nil
+	return &c, nil
+}
+
+// GetAll lists customers, optionally matching search against name, phone,
+// email or member code; deleted ones only when includeDeleted is set.
+func (repo *CustomerRepository) GetAll(ctx context.Context, search string, includeDeleted bool) ([]models.Customer, error) {
+	query := "SELECT " + customerColumns + " FROM customers c WHERE 1 = 1"
+	args := []interface{}{}
+
+	if !includeDeleted {
+		query += " AND c.deleted_at IS NULL"
+	}
+	if search != "" {
+		query += " AND (c.name ILIKE $1 OR c.phone ILIKE $1 OR c.email ILIKE $1 OR c.member_code ILIKE $1)"
+		args = append(args, "%"+search+"%")
+	}
+	query += " ORDER BY c.name, c.id"
+
+	rows, err := repo.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	customers := make([]models.Customer, 0)
+	for rows.Next() {
+		c, err := scanCustomer(rows)
+		if err != nil {
+			return nil, err
+		}
+		customers = append(customers, *c)
+	}
+
+	return customers, rows.Err()
+}
+
+// GetByID returns the customer; a deleted one is not found unless includeDeleted is set.
+func (repo *CustomerRepository) GetByID(ctx context.Context, id int, includeDeleted bool) (*models.Customer, error) {
+	query := "SELECT " + customerColumns + " FROM customers c WHERE c.id = $1"
+	if !includeDeleted {
+		query += " AND c.deleted_at IS NULL"
+	}
+
+	c, err := scanCustomer(repo.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("customer not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Create adds the customer; an empty member code becomes M followed by the
+// zero-padded ID.
+func (repo *CustomerRepository) Create(ctx context.Context, customer *models.Customer) error {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO customers (name, phone, email, member_code, credit_limit)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5) RETURNING id, created_at`,
+		customer.Name, customer.Phone, customer.Email, customer.MemberCode, customer.CreditLimit).Scan(&customer.ID, &customer.CreatedAt)
+	if err != nil {
+		return mapDBError(err, "member code is already in use")
+	}
+	if customer.MemberCode == "" {
+		err = tx.QueryRowContext(ctx, `
+			UPDATE customers SET member_code = 'M' || LPAD(id::text, 6, '0')
+			WHERE id = $1 RETURNING member_code`, customer.ID).Scan(&customer.MemberCode)
+		if err != nil {
+			return mapDBError(err, "member code is already in use")
+		}
+	}
+
+	err = recordAudit(ctx, tx, models.AuditEntityCustomer, customer.ID, models.AuditActionCreate, nil, customerRecord(customer))
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (repo *CustomerRepository) Update(ctx context.Context, customer *models.Customer) error {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := lockCustomer(ctx, tx, customer.ID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE customers SET name = $1, phone = $2, email = $3, member_code = $4 WHERE id = $5",
+		customer.Name, customer.Phone, customer.Email, customer.MemberCode, customer.ID)
+	if err != nil {
+		return mapDBError(err, "member code is already in use")
+	}
+
+	err = recordAudit(ctx, tx, models.AuditEntityCustomer, customer.ID, models.AuditActionUpdate, customerRecord(before), customerRecord(customer))
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SetCreditLimit changes what the customer may owe on kasbon. A limit below
+// what they already owe only stops further kasbon sales.
+func (repo *CustomerRepository) SetCreditLimit(ctx context.Context, id, limit int) (*models.Customer, error) {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	before, err := lockCustomer(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE customers SET credit_limit = $1 WHERE id = $2", limit, id); err != nil {
+		return nil, err
+	}
+	after := *before
+	after.CreditLimit = limit
+
+	err = recordAudit(ctx, tx, models.AuditEntityCustomer, id, models.AuditActionUpdate, customerRecord(before), customerRecord(&after))
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &after, nil
+}
+
+// Delete soft-deletes the customer. Past sales and points stay; the customer
+// can no longer be attached to a checkout.
+func (repo *CustomerRepository) Delete(ctx context.Context, id int) error {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := lockCustomer(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE customers SET deleted_at = NOW() WHERE id = $1", id); err != nil {
+		return err
+	}
+
+	err = recordAudit(ctx, tx, models.AuditEntityCustomer, id, models.AuditActionDelete, customerRecord(before), nil)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Ledger returns the customer's point changes, newest first.
+func (repo *CustomerRepository) Ledger(ctx context.Context, customerID int) ([]models.LoyaltyEntry, error) {
+	rows, err := repo.db.QueryContext(ctx, `
+		SELECT id, transaction_id, type, points, created_at
+		FROM loyalty_ledger
+		WHERE customer_id = $1
+		ORDER BY id DESC`, customerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]models.LoyaltyEntry, 0)
+	for rows.Next() {
+		var e models.LoyaltyEntry
+		if err := rows.Scan(&e.ID, &e.TransactionID, &e.Type, &e.Points, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
+
+// Purchases returns the customer's transactions, newest first.
+func (repo *CustomerRepository) Purchases(ctx context.Context, customerID, limit int) ([]models.CustomerPurchase, error) {
+	rows, err := repo.db.QueryContext(ctx, `
+		SELECT t.id, t.total_amount, t.payment_method, t.status,
+		       (SELECT COALESCE(SUM(td.quantity), 0) FROM transaction_details td WHERE td.transaction_id = t.id),
+		       t.points_redeemed, t.points_earned, t.created_at
+		FROM transactions t
+		WHERE t.customer_id = $1
+		ORDER BY t.created_at DESC, t.id DESC
+		LIMIT $2`, customerID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	purchases := make([]models.CustomerPurchase, 0)
+	for rows.Next() {
+		var p models.CustomerPurchase
+		err := rows.Scan(&p.TransactionID, &p.TotalAmount, &p.PaymentMethod, &p.Status, &p.Items,
+			&p.PointsRedeemed, &p.PointsEarned, &p.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		purchases = append(purchases, p)
+	}
+
+	return purchases, rows.Err()
+}
+
+// lockCustomer reads the customer for a change within tx and keeps others
+// from spending the same points meanwhile.
+func lockCustomer(ctx context.Context, tx *sql.Tx, id int) (*models.Customer, error) {
+	var locked int
+	err := tx.QueryRowContext(ctx, "SELECT id FROM customers WHERE id = $1 AND deleted_at IS NULL FOR UPDATE", id).Scan(&locked)
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("customer not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return scanCustomer(tx.QueryRowContext(ctx, "SELECT "+customerColumns+" FROM customers c WHERE c.id = $1", id))
+}
+
+func addLoyaltyEntry(ctx context.Context, tx *sql.Tx, customerID, transactionID int, entryType string, points int) error {
+	_, err := tx.ExecContext(ctx, "INSERT INTO loyalty_ledger (customer_id, transaction_id, type, points) VALUES ($1, $2, $3, $4)",
+		customerID, transactionID, entryType, points)
+	return err
+}
+
+func customerRecord(c *models.Customer) audit.Record {
+	return audit.Record{"name": c.Name, "phone": c.Phone, "email": c.Email, "member_code": c.MemberCode, "credit_limit": c.CreditLimit}
+}
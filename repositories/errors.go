@@ -0,0 +1,28 @@
+package repositories
+
+import (
+	"errors"
+
+	"kasir-api/apperror"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Postgres error codes we translate into domain errors
+const (
+	pgForeignKeyViolation = "23503"
+	pgUniqueViolation     = "23505"
+)
+
+// mapDBError turns constraint violations into conflicts; everything else is
+// returned unchanged and ends up as an internal error.
+func mapDBError(err error, conflictMessage string) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case pgForeignKeyViolation, pgUniqueViolation:
+			return apperror.Wrap(apperror.CodeConflict, err, conflictMessage)
+		}
+	}
+	return err
+}
@@ -0,0 +1,289 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"kasir-api/apperror"
+	"kasir-api/audit"
+	"kasir-api/models"
+)
+
+type ModifierRepository struct {
+	db *sql.DB
+}
+
+func NewModifierRepository(db *sql.DB) *ModifierRepository {
+	return &ModifierRepository{db: db}
+}
+
+const modifierGroupColumns = "g.id, g.name, g.product_id, g.category_id, g.min_select, g.max_select, g.created_at, g.deleted_at"
+
+// loadModifierGroups returns the groups matching where, a condition on
+// modifier_groups aliased as g, with their options.
+func loadModifierGroups(ctx context.Context, q queryer, where string, args ...any) ([]models.ModifierGroup, error) {
+	rows, err := q.QueryContext(ctx, "SELECT "+modifierGroupColumns+" FROM modifier_groups g WHERE "+where+" ORDER BY g.id", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	groups := make([]models.ModifierGroup, 0)
+	index := make(map[int]int)
+	for rows.Next() {
+		var g models.ModifierGroup
+		err := rows.Scan(&g.ID, &g.Name, &g.ProductID, &g.CategoryID, &g.MinSelect, &g.MaxSelect, &g.CreatedAt, &g.DeletedAt)
+		if err != nil {
+			return nil, err
+		}
+		g.Options = make([]models.Modifier, 0)
+		index[g.ID] = len(groups)
+		groups = append(groups, g)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	rows, err = q.QueryContext(ctx, `
+		SELECT m.group_id, m.id, m.name, m.price_delta
+		FROM modifiers m
+		JOIN modifier_groups g ON g.id = m.group_id
+		WHERE `+where+`
+		ORDER BY m.id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var groupID int
+		var m models.Modifier
+		if err := rows.Scan(&groupID, &m.ID, &m.Name, &m.PriceDelta); err != nil {
+			return nil, err
+		}
+		if i, ok := index[groupID]; ok {
+			groups[i].Options = append(groups[i].Options, m)
+		}
+	}
+
+	return groups, rows.Err()
+}
+
+// GetAll lists modifier groups; deleted ones only when includeDeleted is set.
+func (repo *ModifierRepository) GetAll(ctx context.Context, includeDeleted bool) ([]models.ModifierGroup, error) {
+	where := "g.deleted_at IS NULL"
+	if includeDeleted {
+		where = "TRUE"
+	}
+	return loadModifierGroups(ctx, repo.db, where)
+}
+
+// GetByID returns the group; a deleted one is not found unless includeDeleted is set.
+func (repo *ModifierRepository) GetByID(ctx context.Context, id int, includeDeleted bool) (*models.ModifierGroup, error) {
+	where := "g.id = $1"
+	if !includeDeleted {
+		where += " AND g.deleted_at IS NULL"
+	}
+
+	groups, err := loadModifierGroups(ctx, repo.db, where, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(groups) == 0 {
+		return nil, apperror.NotFound("modifier group not found")
+	}
+	return &groups[0], nil
+}
+
+// ForProduct returns the groups offered on the product: its own and those of its category.
+func (repo *ModifierRepository) ForProduct(ctx context.Context, productID int) ([]models.ModifierGroup, error) {
+	return productModifierGroups(ctx, repo.db, productID)
+}
+
+func productModifierGroups(ctx context.Context, q queryer, productID int) ([]models.ModifierGroup, error) {
+	return loadModifierGroups(ctx, q, `g.deleted_at IS NULL
+		AND (g.product_id = $1 OR g.category_id = (SELECT category_id FROM products WHERE id = $1))`, productID)
+}
+
+func (repo *ModifierRepository) Create(ctx context.Context, group *models.ModifierGroup) error {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO modifier_groups (name, product_id, category_id, min_select, max_select)
+		VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at`,
+		group.Name, group.ProductID, group.CategoryID, group.MinSelect, group.MaxSelect).Scan(&group.ID, &group.CreatedAt)
+	if err != nil {
+		return mapDBError(err, "product or category does not exist")
+	}
+	if err := insertModifiers(ctx, tx, group); err != nil {
+		return err
+	}
+
+	err = recordAudit(ctx, tx, models.AuditEntityModifierGroup, group.ID, models.AuditActionCreate, nil, modifierGroupRecord(group))
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Update replaces the group and its options. Options get new IDs; sold lines
+// keep their own copy.
+func (repo *ModifierRepository) Update(ctx context.Context, group *models.ModifierGroup) error {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := lockModifierGroup(ctx, tx, group.ID)
+	if err != nil {
+		return err
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		UPDATE modifier_groups
+		SET name = $1, product_id = $2, category_id = $3, min_select = $4, max_select = $5
+		WHERE id = $6
+		RETURNING created_at`,
+		group.Name, group.ProductID, group.CategoryID, group.MinSelect, group.MaxSelect, group.ID).Scan(&group.CreatedAt)
+	if err != nil {
+		return mapDBError(err, "product or category does not exist")
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM modifiers WHERE group_id = $1", group.ID); err != nil {
+		return err
+	}
+	if err := insertModifiers(ctx, tx, group); err != nil {
+		return err
+	}
+
+	err = recordAudit(ctx, tx, models.AuditEntityModifierGroup, group.ID, models.AuditActionUpdate, modifierGroupRecord(before), modifierGroupRecord(group))
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Delete soft-deletes the group; it is no longer offered at checkout.
+func (repo *ModifierRepository) Delete(ctx context.Context, id int) error {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := lockModifierGroup(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE modifier_groups SET deleted_at = NOW() WHERE id = $1", id); err != nil {
+		return err
+	}
+
+	err = recordAudit(ctx, tx, models.AuditEntityModifierGroup, id, models.AuditActionDelete, modifierGroupRecord(before), nil)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func lockModifierGroup(ctx context.Context, tx *sql.Tx, id int) (*models.ModifierGroup, error) {
+	var locked int
+	err := tx.QueryRowContext(ctx, "SELECT id FROM modifier_groups WHERE id = $1 AND deleted_at IS NULL FOR UPDATE", id).Scan(&locked)
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("modifier group not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	groups, err := loadModifierGroups(ctx, tx, "g.id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+	return &groups[0], nil
+}
+
+func insertModifiers(ctx context.Context, tx *sql.Tx, group *models.ModifierGroup) error {
+	for i := range group.Options {
+		m := &group.Options[i]
+		err := tx.QueryRowContext(ctx, "INSERT INTO modifiers (group_id, name, price_delta) VALUES ($1, $2, $3) RETURNING id",
+			group.ID, m.Name, m.PriceDelta).Scan(&m.ID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func modifierGroupRecord(g *models.ModifierGroup) audit.Record {
+	options := make([]audit.Record, 0, len(g.Options))
+	for _, m := range g.Options {
+		options = append(options, audit.Record{"name": m.Name, "price_delta": m.PriceDelta})
+	}
+	return audit.Record{
+		"name":        g.Name,
+		"product_id":  g.ProductID,
+		"category_id": g.CategoryID,
+		"min_select":  g.MinSelect,
+		"max_select":  g.MaxSelect,
+		"options":     options,
+	}
+}
+
+// resolveModifiers checks the modifiers chosen for a product against the
+// groups it offers and returns them with their total price delta.
+func resolveModifiers(ctx context.Context, tx *sql.Tx, productID int, productName string, chosen []int) ([]models.TransactionDetailModifier, int, error) {
+	groups, err := productModifierGroups(ctx, tx, productID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(groups) == 0 && len(chosen) == 0 {
+		return nil, 0, nil
+	}
+
+	picked := make(map[int]bool, len(chosen))
+	for _, id := range chosen {
+		picked[id] = true
+	}
+
+	var modifiers []models.TransactionDetailModifier
+	delta := 0
+	for _, g := range groups {
+		count := 0
+		for _, m := range g.Options {
+			if !picked[m.ID] {
+				continue
+			}
+			delete(picked, m.ID)
+			count++
+			delta += m.PriceDelta
+			modifiers = append(modifiers, models.TransactionDetailModifier{
+				ModifierID: m.ID,
+				GroupName:  g.Name,
+				Name:       m.Name,
+				PriceDelta: m.PriceDelta,
+			})
+		}
+		if count < g.MinSelect || count > g.MaxSelect {
+			return nil, 0, apperror.Validation("modifiers", fmt.Sprintf("%s of %s takes %s", g.Name, productName, selectionRange(g)))
+		}
+	}
+	for id := range picked {
+		return nil, 0, apperror.Validation("modifiers", fmt.Sprintf("modifier %d is not offered on %s", id, productName))
+	}
+
+	return modifiers, delta, nil
+}
+
+func selectionRange(g models.ModifierGroup) string {
+	if g.MinSelect == g.MaxSelect {
+		return fmt.Sprintf("exactly %d option(s)", g.MinSelect)
+	}
+	return fmt.Sprintf("%d to %d option(s)", g.MinSelect, g.MaxSelect)
+}
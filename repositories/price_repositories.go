@@ -0,0 +1,140 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"kasir-api/apperror"
+	"kasir-api/audit"
+	"kasir-api/models"
+	"time"
+)
+
+type PriceRepository struct {
+	db *sql.DB
+}
+
+func NewPriceRepository(db *sql.DB) *PriceRepository {
+	return &PriceRepository{db: db}
+}
+
+// effectivePrice is the SQL for the price in effect right now of the product
+// row aliased as alias. products.price is the fallback for a product without
+// any price history.
+func effectivePrice(alias string) string {
+	return `COALESCE((
+			SELECT pp.price FROM product_prices pp
+			WHERE pp.product_id = ` + alias + `.id AND pp.effective_at <= NOW()
+			ORDER BY pp.effective_at DESC, pp.id DESC
+			LIMIT 1), ` + alias + `.price)`
+}
+
+// recordPrice adds a price taking effect immediately to the product's history.
+func recordPrice(ctx context.Context, tx *sql.Tx, productID, price int) error {
+	_, err := tx.ExecContext(ctx,
+		"INSERT INTO product_prices (product_id, price, effective_at, created_by) VALUES ($1, $2, NOW(), $3)",
+		productID, price, audit.Actor(ctx))
+	return err
+}
+
+// List returns the price history of a product, split into prices that took
+// effect (newest first) and scheduled ones (soonest first).
+func (repo *PriceRepository) List(ctx context.Context, productID int) (history, upcoming []models.ProductPrice, err error) {
+	rows, err := repo.db.QueryContext(ctx, `
+		SELECT id, product_id, price, effective_at, created_by, created_at, effective_at <= NOW()
+		FROM product_prices
+		WHERE product_id = $1
+		ORDER BY effective_at DESC, id DESC`, productID)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	history = make([]models.ProductPrice, 0)
+	upcoming = make([]models.ProductPrice, 0)
+	for rows.Next() {
+		var p models.ProductPrice
+		var inEffect bool
+		err := rows.Scan(&p.ID, &p.ProductID, &p.Price, &p.EffectiveAt, &p.CreatedBy, &p.CreatedAt, &inEffect)
+		if err != nil {
+			return nil, nil, err
+		}
+		if inEffect {
+			history = append(history, p)
+		} else {
+			upcoming = append([]models.ProductPrice{p}, upcoming...)
+		}
+	}
+
+	return history, upcoming, rows.Err()
+}
+
+// Schedule adds a price that takes effect at effectiveAt.
+func (repo *PriceRepository) Schedule(ctx context.Context, productID, price int, effectiveAt time.Time) (*models.ProductPrice, error) {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := lockProduct(ctx, tx, productID, false); err != nil {
+		return nil, err
+	}
+
+	p := models.ProductPrice{ProductID: productID, Price: price, EffectiveAt: effectiveAt, CreatedBy: audit.Actor(ctx)}
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO product_prices (product_id, price, effective_at, created_by)
+		VALUES ($1, $2, $3, $4) RETURNING id, created_at`,
+		productID, price, effectiveAt, p.CreatedBy).Scan(&p.ID, &p.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	err = recordAudit(ctx, tx, models.AuditEntityProduct, productID, models.AuditActionSchedulePrice, nil, priceRecord(&p))
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Cancel removes a scheduled price. Prices already in effect are history and stay.
+func (repo *PriceRepository) Cancel(ctx context.Context, productID, priceID int) error {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var p models.ProductPrice
+	var inEffect bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT id, product_id, price, effective_at, effective_at <= NOW()
+		FROM product_prices
+		WHERE id = $1 AND product_id = $2
+		FOR UPDATE`, priceID, productID).Scan(&p.ID, &p.ProductID, &p.Price, &p.EffectiveAt, &inEffect)
+	if err == sql.ErrNoRows {
+		return apperror.NotFound("price not found")
+	}
+	if err != nil {
+		return err
+	}
+	if inEffect {
+		return apperror.Conflict("price is already in effect; set a new price instead")
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM product_prices WHERE id = $1", priceID); err != nil {
+		return err
+	}
+
+	err = recordAudit(ctx, tx, models.AuditEntityProduct, productID, models.AuditActionCancelPrice, priceRecord(&p), nil)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func priceRecord(p *models.ProductPrice) audit.Record {
+	return audit.Record{"price_id": p.ID, "price": p.Price, "effective_at": p.EffectiveAt}
+}
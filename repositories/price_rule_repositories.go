@@ -0,0 +1,244 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"kasir-api/apperror"
+	"kasir-api/audit"
+	"kasir-api/models"
+	"time"
+)
+
+type PriceRuleRepository struct {
+	db *sql.DB
+}
+
+func NewPriceRuleRepository(db *sql.DB) *PriceRuleRepository {
+	return &PriceRuleRepository{db: db}
+}
+
+const priceRuleColumns = `id, name, product_id, category_id, type, value, days_mask,
+	to_char(start_time, 'HH24:MI'), to_char(end_time, 'HH24:MI'), created_at, deleted_at`
+
+func scanPriceRule(row interface{ Scan(...interface{}) error }) (*models.PriceRule, error) {
+	var r models.PriceRule
+	var mask int
+	err := row.Scan(&r.ID, &r.Name, &r.ProductID, &r.CategoryID, &r.Type, &r.Value, &mask,
+		&r.StartTime, &r.EndTime, &r.CreatedAt, &r.DeletedAt)
+	if err != nil {
+		return nil, err
+	}
+	r.Days = maskDays(mask)
+	return &r, nil
+}
+
+// daysMask packs day names into a bit set with bit n for time.Weekday n.
+func daysMask(days []string) int {
+	mask := 0
+	for _, day := range days {
+		for i, name := range models.Weekdays {
+			if day == name {
+				mask |= 1 << i
+			}
+		}
+	}
+	return mask
+}
+
+func maskDays(mask int) []string {
+	days := make([]string, 0, len(models.Weekdays))
+	for i, name := range models.Weekdays {
+		if mask&(1<<i) != 0 {
+			days = append(days, name)
+		}
+	}
+	return days
+}
+
+// GetAll lists price rules; deleted ones only when includeDeleted is set.
+func (repo *PriceRuleRepository) GetAll(ctx context.Context, includeDeleted bool) ([]models.PriceRule, error) {
+	query := "SELECT " + priceRuleColumns + " FROM price_rules"
+	if !includeDeleted {
+		query += " WHERE deleted_at IS NULL"
+	}
+	query += " ORDER BY id"
+
+	rows, err := repo.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := make([]models.PriceRule, 0)
+	for rows.Next() {
+		r, err := scanPriceRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, *r)
+	}
+
+	return rules, rows.Err()
+}
+
+// GetByID returns the price rule; a deleted one is not found unless includeDeleted is set.
+func (repo *PriceRuleRepository) GetByID(ctx context.Context, id int, includeDeleted bool) (*models.PriceRule, error) {
+	query := "SELECT " + priceRuleColumns + " FROM price_rules WHERE id = $1"
+	if !includeDeleted {
+		query += " AND deleted_at IS NULL"
+	}
+
+	r, err := scanPriceRule(repo.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("price rule not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (repo *PriceRuleRepository) Create(ctx context.Context, rule *models.PriceRule) error {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	created, err := scanPriceRule(tx.QueryRowContext(ctx, `
+		INSERT INTO price_rules (name, product_id, category_id, type, value, days_mask, start_time, end_time)
+		VALUES ($1, $2, $3, $4, $5, $6, $7::time, $8::time)
+		RETURNING `+priceRuleColumns,
+		rule.Name, rule.ProductID, rule.CategoryID, rule.Type, rule.Value, daysMask(rule.Days), rule.StartTime, rule.EndTime))
+	if err != nil {
+		return mapDBError(err, "product or category does not exist")
+	}
+
+	err = recordAudit(ctx, tx, models.AuditEntityPriceRule, created.ID, models.AuditActionCreate, nil, priceRuleRecord(created))
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	*rule = *created
+	return nil
+}
+
+// Update replaces every field of the rule.
+func (repo *PriceRuleRepository) Update(ctx context.Context, rule *models.PriceRule) error {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := lockPriceRule(ctx, tx, rule.ID)
+	if err != nil {
+		return err
+	}
+
+	updated, err := scanPriceRule(tx.QueryRowContext(ctx, `
+		UPDATE price_rules
+		SET name = $1, product_id = $2, category_id = $3, type = $4, value = $5, days_mask = $6,
+		    start_time = $7::time, end_time = $8::time
+		WHERE id = $9
+		RETURNING `+priceRuleColumns,
+		rule.Name, rule.ProductID, rule.CategoryID, rule.Type, rule.Value, daysMask(rule.Days), rule.StartTime, rule.EndTime, rule.ID))
+	if err != nil {
+		return mapDBError(err, "product or category does not exist")
+	}
+
+	err = recordAudit(ctx, tx, models.AuditEntityPriceRule, rule.ID, models.AuditActionUpdate, priceRuleRecord(before), priceRuleRecord(updated))
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	*rule = *updated
+	return nil
+}
+
+// Delete soft-deletes the rule; lines priced by it keep referring to it.
+func (repo *PriceRuleRepository) Delete(ctx context.Context, id int) error {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := lockPriceRule(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE price_rules SET deleted_at = NOW() WHERE id = $1", id); err != nil {
+		return err
+	}
+
+	err = recordAudit(ctx, tx, models.AuditEntityPriceRule, id, models.AuditActionDelete, priceRuleRecord(before), nil)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func lockPriceRule(ctx context.Context, tx *sql.Tx, id int) (*models.PriceRule, error) {
+	r, err := scanPriceRule(tx.QueryRowContext(ctx,
+		"SELECT "+priceRuleColumns+" FROM price_rules WHERE id = $1 AND deleted_at IS NULL FOR UPDATE", id))
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("price rule not found")
+	}
+	return r, err
+}
+
+func priceRuleRecord(r *models.PriceRule) audit.Record {
+	return audit.Record{
+		"name":        r.Name,
+		"product_id":  r.ProductID,
+		"category_id": r.CategoryID,
+		"type":        r.Type,
+		"value":       r.Value,
+		"days":        r.Days,
+		"start_time":  r.StartTime,
+		"end_time":    r.EndTime,
+	}
+}
+
+// bestPriceRule returns the rule giving the lowest unit price for the product
+// at at (store local time), or nil when no rule applies or none lowers price.
+// The part of an overnight window after midnight belongs to the day it
+// started on, so it is matched against the previous day.
+func bestPriceRule(ctx context.Context, tx *sql.Tx, productID, categoryID, price int, at time.Time) (*models.PriceRule, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT `+priceRuleColumns+`
+		FROM price_rules
+		WHERE deleted_at IS NULL
+		  AND (product_id = $1 OR category_id = $2)
+		  AND CASE WHEN start_time < end_time
+		           THEN days_mask & $3 <> 0 AND $4::time >= start_time AND $4::time < end_time
+		           ELSE (days_mask & $3 <> 0 AND $4::time >= start_time)
+		             OR (days_mask & $5 <> 0 AND $4::time < end_time)
+		      END
+		ORDER BY id`,
+		productID, categoryID, 1<<int(at.Weekday()), at.Format("15:04:05"), 1<<((int(at.Weekday())+6)%7))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var best *models.PriceRule
+	bestPrice := price
+	for rows.Next() {
+		r, err := scanPriceRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		if p := r.Apply(price); p < bestPrice {
+			best, bestPrice = r, p
+		}
+	}
+
+	return best, rows.Err()
+}
@@ -0,0 +1,65 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"kasir-api/database/dbtest"
+	"kasir-api/models"
+)
+
+func TestBestPriceRuleOvernightWindow(t *testing.T) {
+	db := dbtest.Open(t)
+	ctx := context.Background()
+
+	category := &models.Category{Name: "Minuman"}
+	if err := NewCategoryRepository(db).Create(ctx, category); err != nil {
+		t.Fatalf("create category: %v", err)
+	}
+	product := &models.Product{Name: "Es Teh", Price: 10000, Stock: 10, CategoryId: category.ID}
+	if err := NewProductRepository(db).Create(ctx, product); err != nil {
+		t.Fatalf("create product: %v", err)
+	}
+	rule := &models.PriceRule{Name: "Friday late night", ProductID: &product.ID, Type: models.PriceRulePercentOff,
+		Value: 50, Days: []string{"fri"}, StartTime: "22:00", EndTime: "02:00"}
+	if err := NewPriceRuleRepository(db).Create(ctx, rule); err != nil {
+		t.Fatalf("create price rule: %v", err)
+	}
+
+	// 2026-10-16 is a Friday.
+	tests := []struct {
+		at      string
+		applies bool
+	}{
+		{"2026-10-16 21:59", false},
+		{"2026-10-16 22:00", true},
+		{"2026-10-16 23:30", true},
+		{"2026-10-17 00:00", true},
+		{"2026-10-17 01:59", true},
+		{"2026-10-17 02:00", false},
+		{"2026-10-16 01:00", false},
+		{"2026-10-17 23:00", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.at, func(t *testing.T) {
+			at, err := time.Parse("2006-01-02 15:04", tt.at)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tx, err := db.BeginTx(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			got, err := bestPriceRule(ctx, tx, product.ID, category.ID, product.Price, at)
+			if err != nil {
+				t.Fatalf("bestPriceRule: %v", err)
+			}
+			if applies := got != nil && got.ID == rule.ID; applies != tt.applies {
+				t.Errorf("rule applies = %v, want %v", applies, tt.applies)
+			}
+		})
+	}
+}
@@ -1,8 +1,10 @@
 package repositories
 
 import (
+	"context"
 	"database/sql"
-	"errors"
+	"kasir-api/apperror"
+	"kasir-api/audit"
 	"kasir-api/models"
 )
 
@@ -14,21 +16,26 @@ func NewProductRepository(db *sql.DB) *ProductRepository {
 	return &ProductRepository{db: db}
 }
 
-func (repo *ProductRepository) GetAll(name string) ([]models.Product, error) {
+// GetAll lists products; soft-deleted ones only when includeDeleted is set.
+func (repo *ProductRepository) GetAll(ctx context.Context, name string, includeDeleted bool) ([]models.Product, error) {
 	query := `
-		SELECT products.id, products.name, products.price, products.stock, products.category_id, 
-		       COALESCE(categories.name, ''), COALESCE(categories.description, '')
+		SELECT products.id, products.name, products.sku, ` + effectivePrice("products") + `, products.cost, ` + availableStock("products") + `, products.is_bundle, products.category_id, products.deleted_at,
+		       COALESCE(categories.name, ''), COALESCE(categories.description, ''), categories.deleted_at
 		FROM products
-		LEFT JOIN categories ON products.category_id = categories.id`
+		LEFT JOIN categories ON products.category_id = categories.id
+		WHERE 1 = 1`
 
 	args := []interface{}{}
 
+	if !includeDeleted {
+		query += " AND products.deleted_at IS NULL"
+	}
 	if name != "" {
-		query += " WHERE products.name ILIKE $1"
+		query += " AND products.name ILIKE $1"
 		args = append(args, "%"+name+"%")
 	}
 
-	rows, err := repo.db.Query(query, args...)
+	rows, err := repo.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -36,91 +43,203 @@ func (repo *ProductRepository) GetAll(name string) ([]models.Product, error) {
 
 	products := make([]models.Product, 0)
 	for rows.Next() {
-		var p models.Product
-		err := rows.Scan(
-			&p.ID, &p.Name, &p.Price, &p.Stock, &p.CategoryId,
-			&p.Category.Name, &p.Category.Description,
-		)
+		p, err := scanProduct(rows)
 		if err != nil {
 			return nil, err
 		}
-		p.Category.ID = p.CategoryId
-		products = append(products, p)
+		products = append(products, *p)
 	}
 
-	return products, nil
+	return products, rows.Err()
 }
 
-func (repo *ProductRepository) GetByID(id int) (*models.Product, error) {
+// GetByID returns the product; a soft-deleted one is not found unless includeDeleted is set.
+func (repo *ProductRepository) GetByID(ctx context.Context, id int, includeDeleted bool) (*models.Product, error) {
 	query := `
-		SELECT products.id, products.name, products.price, products.stock, products.category_id, 
-			   COALESCE(categories.name, ''), COALESCE(categories.description, '')
+		SELECT products.id, products.name, products.sku, ` + effectivePrice("products") + `, products.cost, ` + availableStock("products") + `, products.is_bundle, products.category_id, products.deleted_at,
+			   COALESCE(categories.name, ''), COALESCE(categories.description, ''), categories.deleted_at
 		FROM products
 		LEFT JOIN categories ON products.category_id = categories.id
 		WHERE products.id = $1`
+	if !includeDeleted {
+		query += " AND products.deleted_at IS NULL"
+	}
 
-	var p models.Product
-	err := repo.db.QueryRow(query, id).Scan(
-		&p.ID, &p.Name, &p.Price, &p.Stock, &p.CategoryId,
-		&p.Category.Name, &p.Category.Description,
-	)
+	p, err := scanProduct(repo.db.QueryRowContext(ctx, query, id))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, errors.New("product not found")
+			return nil, apperror.NotFound("product not found")
 		}
 		return nil, err
 	}
 
+	return p, nil
+}
+
+func scanProduct(row interface{ Scan(...any) error }) (*models.Product, error) {
+	var p models.Product
+	err := row.Scan(
+		&p.ID, &p.Name, &p.SKU, &p.Price, &p.Cost, &p.Stock, &p.IsBundle, &p.CategoryId, &p.DeletedAt,
+		&p.Category.Name, &p.Category.Description, &p.Category.DeletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	p.Active = p.DeletedAt == nil
 	p.Category.ID = p.CategoryId
+	p.Category.Active = p.Category.DeletedAt == nil
 	return &p, nil
 }
 
-func (repo *ProductRepository) Create(product *models.Product) error {
-	query := "INSERT INTO products (name, price, stock, category_id) VALUES ($1, $2, $3, $4) RETURNING id"
-	err := repo.db.QueryRow(query, product.Name, product.Price, product.Stock, product.CategoryId).Scan(&product.ID)
-	return err
+func (repo *ProductRepository) Create(ctx context.Context, product *models.Product) error {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := "INSERT INTO products (name, sku, price, cost, stock, category_id) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id"
+	err = tx.QueryRowContext(ctx, query, product.Name, product.SKU, product.Price, product.Cost, product.Stock, product.CategoryId).Scan(&product.ID)
+	if err != nil {
+		return mapDBError(err, "category does not exist")
+	}
+	if err := recordPrice(ctx, tx, product.ID, product.Price); err != nil {
+		return err
+	}
+
+	err = recordAudit(ctx, tx, models.AuditEntityProduct, product.ID, models.AuditActionCreate, nil, productRecord(product))
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
-func (repo *ProductRepository) Update(product *models.Product) error {
-	query := "UPDATE products SET name = $1, price = $2, stock = $3, category_id = $4 WHERE id = $5"
-	result, err := repo.db.Exec(query, product.Name, product.Price, product.Stock, product.CategoryId, product.ID)
+func (repo *ProductRepository) Update(ctx context.Context, product *models.Product) error {
+	tx, err := repo.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
-	rows, err := result.RowsAffected()
+	before, err := lockProduct(ctx, tx, product.ID, false)
 	if err != nil {
 		return err
 	}
 
-	if rows == 0 {
-		return errors.New("product not found")
+	// A bundle's stock is derived from its components; its own column is not written.
+	if before.IsBundle {
+		product.Stock = before.Stock
+	}
+
+	query := "UPDATE products SET name = $1, sku = $2, price = $3, cost = $4, stock = $5, category_id = $6 WHERE id = $7"
+	_, err = tx.ExecContext(ctx, query, product.Name, product.SKU, product.Price, product.Cost, product.Stock, product.CategoryId, product.ID)
+	if err != nil {
+		return mapDBError(err, "category does not exist")
+	}
+	if product.Price != before.Price {
+		if err := recordPrice(ctx, tx, product.ID, product.Price); err != nil {
+			return err
+		}
 	}
 
-	return nil
+	err = recordAudit(ctx, tx, models.AuditEntityProduct, product.ID, models.AuditActionUpdate, productRecord(before), productRecord(product))
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
-func (repo *ProductRepository) Delete(id int) error {
-	query := "DELETE FROM products WHERE id = $1"
-	result, err := repo.db.Exec(query, id)
+// Delete soft-deletes the product. Its row stays so past transactions keep
+// resolving it.
+func (repo *ProductRepository) Delete(ctx context.Context, id int) error {
+	tx, err := repo.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
-	rows, err := result.RowsAffected()
+	defer tx.Rollback()
+
+	before, err := lockProduct(ctx, tx, id, false)
 	if err != nil {
 		return err
 	}
 
-	if rows == 0 {
-		return errors.New("product not found")
+	_, err = tx.ExecContext(ctx, "UPDATE products SET deleted_at = NOW() WHERE id = $1", id)
+	if err != nil {
+		return err
 	}
 
-	return nil
+	err = recordAudit(ctx, tx, models.AuditEntityProduct, id, models.AuditActionDelete, productRecord(before), nil)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Restore undoes a soft delete. The product's category must not be deleted.
+func (repo *ProductRepository) Restore(ctx context.Context, id int) error {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	product, err := lockProduct(ctx, tx, id, true)
+	if err != nil {
+		return err
+	}
+	if product.DeletedAt == nil {
+		return apperror.Conflict("product is not deleted")
+	}
+
+	var categoryDeleted bool
+	err = tx.QueryRowContext(ctx, "SELECT deleted_at IS NOT NULL FROM categories WHERE id = $1", product.CategoryId).Scan(&categoryDeleted)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	if categoryDeleted {
+		return apperror.Conflict("category of the product is deleted; restore it first")
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE products SET deleted_at = NULL WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	err = recordAudit(ctx, tx, models.AuditEntityProduct, id, models.AuditActionRestore, nil, productRecord(product))
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// lockProduct reads the product row, with the price in effect, for a change
+// within tx. Soft-deleted products are not found unless includeDeleted is set.
+func lockProduct(ctx context.Context, tx *sql.Tx, id int, includeDeleted bool) (*models.Product, error) {
+	query := "SELECT id, name, sku, " + effectivePrice("products") + ", cost, stock, is_bundle, category_id, deleted_at FROM products WHERE id = $1"
+	if !includeDeleted {
+		query += " AND deleted_at IS NULL"
+	}
+
+	var p models.Product
+	err := tx.QueryRowContext(ctx, query+" FOR UPDATE", id).
+		Scan(&p.ID, &p.Name, &p.SKU, &p.Price, &p.Cost, &p.Stock, &p.IsBundle, &p.CategoryId, &p.DeletedAt)
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("product not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func productRecord(p *models.Product) audit.Record {
+	return audit.Record{"name": p.Name, "sku": p.SKU, "price": p.Price, "cost": p.Cost, "stock": p.Stock, "category_id": p.CategoryId}
 }
 
-func (repo *ProductRepository) Exists(name string, price int, categoryID int) (bool, error) {
+func (repo *ProductRepository) Exists(ctx context.Context, name string, price int, categoryID int) (bool, error) {
 	var exists bool
-	query := "SELECT EXISTS(SELECT 1 FROM products WHERE name = $1 AND price = $2 AND category_id = $3)"
-	err := repo.db.QueryRow(query, name, price, categoryID).Scan(&exists)
+	query := "SELECT EXISTS(SELECT 1 FROM products WHERE name = $1 AND price = $2 AND category_id = $3 AND deleted_at IS NULL)"
+	err := repo.db.QueryRowContext(ctx, query, name, price, categoryID).Scan(&exists)
 	return exists, err
 }
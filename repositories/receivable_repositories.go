@@ -0,0 +1,172 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"kasir-api/apperror"
+	"kasir-api/audit"
+	"kasir-api/models"
+	"time"
+)
+
+type ReceivableRepository struct {
+	db *sql.DB
+}
+
+func NewReceivableRepository(db *sql.DB) *ReceivableRepository {
+	return &ReceivableRepository{db: db}
+}
+
+// receivableBalance is the SQL for what the customer row aliased as alias
+// owes: completed kasbon sales less repayments. Voided and refunded sales
+// drop out, so paying for one leaves the customer in credit.
+func receivableBalance(alias string) string {
+	return `(COALESCE((
+			SELECT SUM(t.total_amount) FROM transactions t
+			WHERE t.customer_id = ` + alias + `.id AND t.payment_method = 'kasbon' AND t.status = 'completed'), 0)
+		- COALESCE((SELECT SUM(rp.amount) FROM receivable_payments rp WHERE rp.customer_id = ` + alias + `.id), 0))`
+}
+
+// Ledger returns the customer's kasbon sales and repayments, oldest first,
+// with the balance after each.
+func (repo *ReceivableRepository) Ledger(ctx context.Context, customerID int) ([]models.ReceivableEntry, error) {
+	rows, err := repo.db.QueryContext(ctx, `
+		SELECT type, transaction_id, payment_id, payment_method, amount, created_at
+		FROM (
+			SELECT 'charge' AS type, t.id AS transaction_id, NULL::int AS payment_id, '' AS payment_method,
+			       t.total_amount AS amount, t.created_at
+			FROM transactions t
+			WHERE t.customer_id = $1 AND t.payment_method = 'kasbon' AND t.status = 'completed'
+			UNION ALL
+			SELECT 'payment', NULL, rp.id, rp.payment_method, -rp.amount, rp.created_at
+			FROM receivable_payments rp
+			WHERE rp.customer_id = $1
+		) entries
+		ORDER BY created_at, type`, customerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]models.ReceivableEntry, 0)
+	balance := 0
+	for rows.Next() {
+		var e models.ReceivableEntry
+		err := rows.Scan(&e.Type, &e.TransactionID, &e.PaymentID, &e.PaymentMethod, &e.Amount, &e.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		balance += e.Amount
+		e.Balance = balance
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
+
+// AddPayment records a repayment, refusing more than the customer owes.
+func (repo *ReceivableRepository) AddPayment(ctx context.Context, payment *models.ReceivablePayment) error {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	customer, err := lockCustomer(ctx, tx, payment.CustomerID)
+	if err != nil {
+		return err
+	}
+	if payment.Amount > customer.Receivable {
+		return apperror.Conflict("payment exceeds the outstanding balance of %d", max(customer.Receivable, 0))
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO receivable_payments (customer_id, amount, payment_method, shift_id, cashier_id, note)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at`,
+		payment.CustomerID, payment.Amount, payment.PaymentMethod, payment.ShiftID, payment.CashierID, payment.Note,
+	).Scan(&payment.ID, &payment.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	after := audit.Record{
+		"payment_id":     payment.ID,
+		"amount":         payment.Amount,
+		"payment_method": payment.PaymentMethod,
+		"balance":        customer.Receivable - payment.Amount,
+	}
+	err = recordAudit(ctx, tx, models.AuditEntityCustomer, payment.CustomerID, models.AuditActionRepayment, nil, after)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// storeDate is the calendar day of a timestamp column, written in the
+// database session's timezone, in the store timezone given by param.
+func storeDate(column, param string) string {
+	return "(timezone(current_setting('TimeZone'), " + column + ") AT TIME ZONE " + param + ")::date"
+}
+
+// Aging splits what each customer owes on asOf by the age in days of the
+// kasbon sales still unpaid. Repayments settle the oldest sales first. Days
+// are counted in the timezone of asOf.
+func (repo *ReceivableRepository) Aging(ctx context.Context, asOf time.Time) ([]models.ReceivableAging, error) {
+	rows, err := repo.db.QueryContext(ctx, `
+		WITH paid AS (
+			SELECT customer_id, SUM(amount) AS amount
+			FROM receivable_payments
+			WHERE `+storeDate("created_at", "$2")+` <= $1::date
+			GROUP BY customer_id
+		), charges AS (
+			SELECT t.customer_id, `+storeDate("t.created_at", "$2")+` AS sold_on, t.total_amount,
+			       SUM(t.total_amount) OVER (PARTITION BY t.customer_id ORDER BY t.created_at, t.id) AS running
+			FROM transactions t
+			WHERE t.payment_method = 'kasbon' AND t.status = 'completed' AND t.customer_id IS NOT NULL
+			  AND `+storeDate("t.created_at", "$2")+` <= $1::date
+		), unpaid AS (
+			SELECT ch.customer_id, $1::date - ch.sold_on AS age,
+			       LEAST(ch.total_amount, GREATEST(ch.running - COALESCE(p.amount, 0), 0)) AS amount
+			FROM charges ch
+			LEFT JOIN paid p ON p.customer_id = ch.customer_id
+		)
+		SELECT c.id, c.name, c.member_code,
+		       COALESCE(SUM(u.amount) FILTER (WHERE u.age <= 30), 0),
+		       COALESCE(SUM(u.amount) FILTER (WHERE u.age BETWEEN 31 AND 60), 0),
+		       COALESCE(SUM(u.amount) FILTER (WHERE u.age > 60), 0),
+		       SUM(u.amount)
+		FROM unpaid u
+		JOIN customers c ON c.id = u.customer_id
+		GROUP BY c.id, c.name, c.member_code
+		HAVING SUM(u.amount) > 0
+		ORDER BY 7 DESC, c.id`, asOf.Format("2006-01-02"), asOf.Location().String())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	aging := make([]models.ReceivableAging, 0)
+	for rows.Next() {
+		var a models.ReceivableAging
+		err := rows.Scan(&a.CustomerID, &a.Name, &a.MemberCode, &a.Current, &a.Days31To60, &a.Over60, &a.Total)
+		if err != nil {
+			return nil, err
+		}
+		aging = append(aging, a)
+	}
+
+	return aging, rows.Err()
+}
+
+// checkCredit refuses a kasbon sale of amount that would take the customer
+// past their credit limit; customer is read with lockCustomer so concurrent
+// sales cannot both use the same credit.
+func checkCredit(customer *models.Customer, amount int) error {
+	if customer.CreditLimit <= 0 {
+		return apperror.Conflict("customer %s has no kasbon credit", customer.Name)
+	}
+	if available := customer.CreditLimit - customer.Receivable; amount > available {
+		return apperror.Conflict("kasbon of %d exceeds the available credit of %d", amount, max(available, 0))
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"kasir-api/apperror"
+	"kasir-api/models"
+)
+
+type ShiftRepository struct {
+	db *sql.DB
+}
+
+func NewShiftRepository(db *sql.DB) *ShiftRepository {
+	return &ShiftRepository{db: db}
+}
+
+const shiftColumns = "id, cashier_id, terminal_id, store_id, opening_float, status, opened_at, closed_at, closed_by"
+
+func scanShift(row interface{ Scan(...interface{}) error }) (*models.Shift, error) {
+	var s models.Shift
+	err := row.Scan(&s.ID, &s.CashierID, &s.TerminalID, &s.StoreID, &s.OpeningFloat,
+		&s.Status, &s.OpenedAt, &s.ClosedAt, &s.ClosedBy)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (repo *ShiftRepository) Open(ctx context.Context, shift *models.Shift) error {
+	query := `
+		INSERT INTO shifts (cashier_id, terminal_id, store_id, opening_float)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, status, opened_at`
+	err := repo.db.QueryRowContext(ctx, query, shift.CashierID, shift.TerminalID, shift.StoreID, shift.OpeningFloat).
+		Scan(&shift.ID, &shift.Status, &shift.OpenedAt)
+	if err != nil {
+		return mapDBError(err, "this terminal already has an open shift")
+	}
+	return nil
+}
+
+func (repo *ShiftRepository) GetByID(ctx context.Context, id int) (*models.Shift, error) {
+	shift, err := scanShift(repo.db.QueryRowContext(ctx, "SELECT "+shiftColumns+" FROM shifts WHERE id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("shift not found")
+	}
+	return shift, err
+}
+
+// GetOpenByTerminal returns the open shift on a register, or nil when there is none.
+func (repo *ShiftRepository) GetOpenByTerminal(ctx context.Context, terminalID string) (*models.Shift, error) {
+	query := "SELECT " + shiftColumns + " FROM shifts WHERE terminal_id = $1 AND status = 'open'"
+	shift, err := scanShift(repo.db.QueryRowContext(ctx, query, terminalID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return shift, err
+}
+
+func (repo *ShiftRepository) AddCashMovement(ctx context.Context, movement *models.CashMovement) error {
+	query := `
+		INSERT INTO shift_cash_movements (shift_id, type, amount, reason, cashier_id)
+		SELECT $1, $2, $3, $4, $5
+		WHERE EXISTS (SELECT 1 FROM shifts WHERE id = $1 AND status = 'open')
+		RETURNING id, created_at`
+	err := repo.db.QueryRowContext(ctx, query, movement.ShiftID, movement.Type, movement.Amount, movement.Reason, movement.CashierID).
+		Scan(&movement.ID, &movement.CreatedAt)
+	if err == sql.ErrNoRows {
+		return apperror.Conflict("shift not found or already closed")
+	}
+	return err
+}
+
+// GetCashMovementTotals returns the summed pay-ins and pay-outs of a shift.
+func (repo *ShiftRepository) GetCashMovementTotals(ctx context.Context, shiftID int) (int, int, error) {
+	var payIns, payOuts int
+	query := `
+		SELECT COALESCE(SUM(amount) FILTER (WHERE type = 'pay_in'), 0),
+		       COALESCE(SUM(amount) FILTER (WHERE type = 'pay_out'), 0)
+		FROM shift_cash_movements
+		WHERE shift_id = $1`
+	err := repo.db.QueryRowContext(ctx, query, shiftID).Scan(&payIns, &payOuts)
+	return payIns, payOuts, err
+}
+
+// GetTenderTotals sums sales rung up during the shift, voids/refunds paid out
+// and kasbon repayments taken during the shift, per payment method. A sale
+// refunded in a later shift counts as a sale here and as a refund there.
+func (repo *ShiftRepository) GetTenderTotals(ctx context.Context, shiftID int) ([]models.TenderTotals, error) {
+	query := `
+		SELECT payment_method, SUM(sales), SUM(sales_count), SUM(refunds), SUM(refund_count), SUM(repayments)
+		FROM (
+			SELECT payment_method,
+			       COALESCE(SUM(total_amount) FILTER (WHERE shift_id = $1), 0) AS sales,
+			       COUNT(*) FILTER (WHERE shift_id = $1) AS sales_count,
+			       COALESCE(SUM(total_amount) FILTER (WHERE status_shift_id = $1), 0) AS refunds,
+			       COUNT(*) FILTER (WHERE status_shift_id = $1) AS refund_count,
+			       0 AS repayments
+			FROM transactions
+			WHERE shift_id = $1 OR status_shift_id = $1
+			GROUP BY payment_method
+			UNION ALL
+			SELECT payment_method, 0, 0, 0, 0, SUM(amount)
+			FROM receivable_payments
+			WHERE shift_id = $1
+			GROUP BY payment_method
+		) tenders
+		GROUP BY payment_method
+		ORDER BY payment_method`
+
+	rows, err := repo.db.QueryContext(ctx, query, shiftID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	totals := make([]models.TenderTotals, 0)
+	for rows.Next() {
+		var t models.TenderTotals
+		err := rows.Scan(&t.PaymentMethod, &t.Sales, &t.SalesCount, &t.Refunds, &t.RefundCount, &t.Repayments)
+		if err != nil {
+			return nil, err
+		}
+		totals = append(totals, t)
+	}
+
+	return totals, rows.Err()
+}
+
+// Close marks the shift closed and stores the counted amounts per payment method.
+func (repo *ShiftRepository) Close(ctx context.Context, id int, counted map[string]int, closedBy string) (*models.Shift, error) {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE shifts SET status = 'closed', closed_at = NOW(), closed_by = $1
+		WHERE id = $2 AND status = 'open'
+		RETURNING ` + shiftColumns
+	shift, err := scanShift(tx.QueryRowContext(ctx, query, closedBy, id))
+	if err == sql.ErrNoRows {
+		return nil, apperror.Conflict("shift not found or already closed")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for method, amount := range counted {
+		_, err := tx.ExecContext(ctx, "INSERT INTO shift_counts (shift_id, payment_method, counted_amount) VALUES ($1, $2, $3)",
+			id, method, amount)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return shift, nil
+}
+
+func (repo *ShiftRepository) GetCounts(ctx context.Context, shiftID int) (map[string]int, error) {
+	rows, err := repo.db.QueryContext(ctx, "SELECT payment_method, counted_amount FROM shift_counts WHERE shift_id = $1", shiftID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counted := make(map[string]int)
+	for rows.Next() {
+		var method string
+		var amount int
+		if err := rows.Scan(&method, &amount); err != nil {
+			return nil, err
+		}
+		counted[method] = amount
+	}
+
+	return counted, rows.Err()
+}
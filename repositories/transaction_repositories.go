@@ -1,9 +1,13 @@
 package repositories
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"kasir-api/apperror"
+	"kasir-api/audit"
 	"kasir-api/models"
+	"log/slog"
 	"time"
 )
 
@@ -15,8 +19,8 @@ func NewTransactionRepository(db *sql.DB) *TransactionRepository {
 	return &TransactionRepository{db: db}
 }
 
-func (repo *TransactionRepository) CreateTransaction(items []models.CheckoutItem, useLock bool) (*models.Transaction, error) {
-	tx, err := repo.db.Begin()
+func (repo *TransactionRepository) CreateTransaction(ctx context.Context, req models.CheckoutRequest, attr models.Attribution, useLock bool) (*models.Transaction, error) {
+	tx, err := repo.db.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -25,92 +29,539 @@ func (repo *TransactionRepository) CreateTransaction(items []models.CheckoutItem
 	totalAmount := 0
 	details := make([]models.TransactionDetail, 0)
 
-	for _, item := range items {
-		var productPrice, stock int
-		var productName string
+	for _, item := range req.Items {
+		var productPrice, productCost, stock, categoryID int
+		var productName, sku string
+		var isBundle bool
 
-		err := tx.QueryRow("SELECT name, price, stock FROM products WHERE id = $1", item.ProductID).Scan(&productName, &productPrice, &stock)
+		err := tx.QueryRowContext(ctx, "SELECT name, sku, "+effectivePrice("products")+", cost, stock, COALESCE(category_id, 0), is_bundle FROM products WHERE id = $1 AND deleted_at IS NULL", item.ProductID).
+			Scan(&productName, &sku, &productPrice, &productCost, &stock, &categoryID, &isBundle)
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("product id %d not found", item.ProductID)
+			return nil, apperror.NotFound("product id %d not found", item.ProductID)
 		}
 
 		if err != nil {
 			return nil, err
 		}
 
-		if stock < item.Quantity {
-			return nil, fmt.Errorf("insufficient stock for product %s", productName)
+		// A bundle's stock and cost come from what it is made of
+		var parts []bundlePart
+		if isBundle {
+			parts, err = bundleParts(ctx, tx, item.ProductID, item.Choices)
+			if err != nil {
+				return nil, err
+			}
+			productCost = 0
+			for _, part := range parts {
+				productCost += part.cost * part.quantity
+			}
+		} else if len(item.Choices) > 0 {
+			return nil, apperror.Validation("choices", fmt.Sprintf("product %s is not a bundle", productName))
+		} else if stock < item.Quantity {
+			return nil, apperror.InsufficientStock("insufficient stock for product %s", productName)
 		}
 
-		subtotal := productPrice * item.Quantity
-		totalAmount += subtotal
+		modifiers, delta, err := resolveModifiers(ctx, tx, item.ProductID, productName, item.Modifiers)
+		if err != nil {
+			return nil, err
+		}
+		unitPrice := max(productPrice+delta, 0)
 
-		_, err = tx.Exec("UPDATE products SET stock = stock - $1 WHERE id = $2", item.Quantity, item.ProductID)
+		// Price rules discount the product's own price, not its modifiers
+		rule, err := bestPriceRule(ctx, tx, item.ProductID, categoryID, productPrice, req.SoldAt)
 		if err != nil {
 			return nil, err
 		}
+		discount := 0
+		var ruleID *int
+		if rule != nil {
+			discount = min((productPrice-rule.Apply(productPrice))*item.Quantity, unitPrice*item.Quantity)
+			ruleID = &rule.ID
+		}
+
+		subtotal := unitPrice*item.Quantity - discount
+		totalAmount += subtotal
+
+		var components []models.TransactionDetailComponent
+		if isBundle {
+			revenue := allocateRevenue(subtotal, parts)
+			for i, part := range parts {
+				if err := deductStock(ctx, tx, part.productID, part.quantity*item.Quantity, part.name, req.CartID); err != nil {
+					return nil, err
+				}
+				components = append(components, models.TransactionDetailComponent{
+					ProductID:     part.productID,
+					ProductName:   part.name,
+					ChoiceGroupID: part.choiceGroupID,
+					Quantity:      part.quantity * item.Quantity,
+					Revenue:       revenue[i],
+					Cost:          part.cost * part.quantity,
+				})
+			}
+		} else if err := deductStock(ctx, tx, item.ProductID, item.Quantity, productName, req.CartID); err != nil {
+			return nil, err
+		}
 
 		details = append(details, models.TransactionDetail{
 			ProductID:   item.ProductID,
 			ProductName: productName,
+			SKU:         sku,
+			UnitPrice:   unitPrice,
 			Quantity:    item.Quantity,
+			Discount:    discount,
 			Subtotal:    subtotal,
+			Cost:        productCost,
+			PriceRuleID: ruleID,
+			Modifiers:   modifiers,
+			Note:        item.Note,
+			Components:  components,
 		})
 	}
 
+	// The voucher comes off the lines' total, checked against its minimum spend
+	var voucherID *int
+	voucherDiscount := 0
+	if req.VoucherCode != "" {
+		voucher, discount, err := redeemVoucher(ctx, tx, req.VoucherCode, req.CustomerID, totalAmount)
+		if err != nil {
+			return nil, err
+		}
+		voucherID, voucherDiscount = &voucher.ID, discount
+		totalAmount -= voucherDiscount
+	}
+
+	// Redeemed points come off what is left; points are earned on what is left to pay
+	pointsDiscount, pointsEarned := 0, 0
+	if req.CustomerID != nil {
+		customer, err := lockCustomer(ctx, tx, *req.CustomerID)
+		if err != nil {
+			return nil, err
+		}
+		if req.RedeemPoints > 0 {
+			if customer.Points < req.RedeemPoints {
+				return nil, apperror.Conflict("customer has only %d points", customer.Points)
+			}
+			pointsDiscount = req.RedeemPoints * req.Loyalty.PointValue
+			if pointsDiscount > totalAmount {
+				return nil, apperror.Validation("redeem_points", fmt.Sprintf("%d points are worth more than the total of %d", req.RedeemPoints, totalAmount))
+			}
+			totalAmount -= pointsDiscount
+		}
+		if req.Loyalty.RupiahPerPoint > 0 {
+			pointsEarned = totalAmount / req.Loyalty.RupiahPerPoint
+		}
+		if req.PaymentMethod == models.PaymentMethodKasbon {
+			if err := checkCredit(customer, totalAmount); err != nil {
+				return nil, err
+			}
+		}
+	}
+
 	var transactionID int
 	var createdAt time.Time
 
-	err = tx.QueryRow("INSERT INTO transactions (total_amount) VALUES ($1) RETURNING id, created_at", totalAmount).Scan(&transactionID, &createdAt)
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO transactions (total_amount, cashier_id, terminal_id, store_id, payment_method, shift_id,
+		                           customer_id, voucher_id, voucher_code, voucher_discount,
+		                           points_redeemed, points_discount, points_earned)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13) RETURNING id, created_at`,
+		totalAmount, attr.CashierID, attr.TerminalID, attr.StoreID, req.PaymentMethod, req.ShiftID,
+		req.CustomerID, voucherID, req.VoucherCode, voucherDiscount,
+		req.RedeemPoints, pointsDiscount, pointsEarned,
+	).Scan(&transactionID, &createdAt)
 	if err != nil {
 		return nil, err
 	}
 
+	if voucherID != nil {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO voucher_redemptions (voucher_id, transaction_id, customer_id, discount)
+			VALUES ($1, $2, $3, $4)`, *voucherID, transactionID, req.CustomerID, voucherDiscount)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if req.RedeemPoints > 0 {
+		if err := addLoyaltyEntry(ctx, tx, *req.CustomerID, transactionID, models.LoyaltyEntryRedeem, -req.RedeemPoints); err != nil {
+			return nil, err
+		}
+	}
+	if pointsEarned > 0 {
+		if err := addLoyaltyEntry(ctx, tx, *req.CustomerID, transactionID, models.LoyaltyEntryEarn, pointsEarned); err != nil {
+			return nil, err
+		}
+	}
+
+	// the cart is linked in the same commit so a converted cart always points at its sale
+	if req.CartID != 0 {
+		res, err := tx.ExecContext(ctx, `
+			UPDATE carts SET transaction_id = $1, reserved_until = NULL, updated_at = NOW()
+			WHERE id = $2 AND status = $3`, transactionID, req.CartID, models.CartStatusConverted)
+		if err != nil {
+			return nil, err
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return nil, err
+		} else if n == 0 {
+			return nil, apperror.Conflict("cart is no longer being checked out")
+		}
+	}
+
 	for i := range details {
-		details[i].TransactionID = transactionID
-		_, err = tx.Exec("INSERT INTO transaction_details (transaction_id, product_id, quantity, subtotal) VALUES ($1, $2, $3, $4)",
-			transactionID, details[i].ProductID, details[i].Quantity, details[i].Subtotal)
+		d := &details[i]
+		d.TransactionID = transactionID
+		err = tx.QueryRowContext(ctx, `
+			INSERT INTO transaction_details (transaction_id, product_id, product_name, sku, unit_price, quantity, discount, subtotal, cost, price_rule_id, note)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING id`,
+			transactionID, d.ProductID, d.ProductName, d.SKU, d.UnitPrice, d.Quantity, d.Discount, d.Subtotal, d.Cost, d.PriceRuleID, d.Note,
+		).Scan(&d.ID)
 		if err != nil {
 			return nil, err
 		}
+
+		for _, m := range d.Modifiers {
+			_, err = tx.ExecContext(ctx, `
+				INSERT INTO transaction_detail_modifiers (detail_id, modifier_id, group_name, name, price_delta)
+				VALUES ($1, $2, $3, $4, $5)`,
+				d.ID, m.ModifierID, m.GroupName, m.Name, m.PriceDelta)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		for _, c := range d.Components {
+			_, err = tx.ExecContext(ctx, `
+				INSERT INTO transaction_detail_components (detail_id, product_id, product_name, choice_group_id, quantity, revenue, cost)
+				VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+				d.ID, c.ProductID, c.ProductName, c.ChoiceGroupID, c.Quantity, c.Revenue, c.Cost)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	transaction := &models.Transaction{
+		ID:              transactionID,
+		TotalAmount:     totalAmount,
+		CashierID:       attr.CashierID,
+		TerminalID:      attr.TerminalID,
+		StoreID:         attr.StoreID,
+		PaymentMethod:   req.PaymentMethod,
+		ShiftID:         req.ShiftID,
+		CustomerID:      req.CustomerID,
+		VoucherID:       voucherID,
+		VoucherCode:     req.VoucherCode,
+		VoucherDiscount: voucherDiscount,
+		PointsRedeemed:  req.RedeemPoints,
+		PointsDiscount:  pointsDiscount,
+		PointsEarned:    pointsEarned,
+		Status:          models.TransactionStatusCompleted,
+		CreatedAt:       createdAt,
+		Details:         details,
+	}
+	err = recordAudit(ctx, tx, models.AuditEntityTransaction, transactionID, models.AuditActionCreate, nil, transactionRecord(transaction))
+	if err != nil {
+		return nil, err
 	}
 
 	if err := tx.Commit(); err != nil {
 		return nil, err
 	}
+	slog.DebugContext(ctx, "transaction committed", slog.Int("transaction_id", transactionID), slog.Int("lines", len(details)))
+
+	return transaction, nil
+}
+
+// GetByID returns the transaction with its lines as they were sold.
+func (repo *TransactionRepository) GetByID(ctx context.Context, id int) (*models.Transaction, error) {
+	var t models.Transaction
+	err := repo.db.QueryRowContext(ctx, `
+		SELECT id, total_amount, cashier_id, terminal_id, store_id, payment_method, shift_id,
+		       customer_id, voucher_id, voucher_code, voucher_discount,
+		       points_redeemed, points_discount, points_earned, status,
+		       status_changed_at, status_changed_by, status_reason, created_at
+		FROM transactions
+		WHERE id = $1`, id).Scan(&t.ID, &t.TotalAmount, &t.CashierID, &t.TerminalID, &t.StoreID,
+		&t.PaymentMethod, &t.ShiftID, &t.CustomerID, &t.VoucherID, &t.VoucherCode, &t.VoucherDiscount,
+		&t.PointsRedeemed, &t.PointsDiscount, &t.PointsEarned,
+		&t.Status, &t.StatusChangedAt, &t.StatusChangedBy, &t.StatusReason, &t.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("transaction not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := repo.db.QueryContext(ctx, `
+		SELECT id, transaction_id, product_id, product_name, sku, unit_price, quantity, discount, subtotal, cost, price_rule_id, note
+		FROM transaction_details
+		WHERE transaction_id = $1
+		ORDER BY id`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	t.Details = make([]models.TransactionDetail, 0)
+	for rows.Next() {
+		var d models.TransactionDetail
+		err := rows.Scan(&d.ID, &d.TransactionID, &d.ProductID, &d.ProductName, &d.SKU,
+			&d.UnitPrice, &d.Quantity, &d.Discount, &d.Subtotal, &d.Cost, &d.PriceRuleID, &d.Note)
+		if err != nil {
+			return nil, err
+		}
+		t.Details = append(t.Details, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	if err := repo.loadModifiers(ctx, t.ID, t.Details); err != nil {
+		return nil, err
+	}
+	if err := repo.loadComponents(ctx, t.ID, t.Details); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// loadModifiers fills in the modifiers chosen on details.
+func (repo *TransactionRepository) loadModifiers(ctx context.Context, transactionID int, details []models.TransactionDetail) error {
+	rows, err := repo.db.QueryContext(ctx, `
+		SELECT m.detail_id, m.modifier_id, m.group_name, m.name, m.price_delta
+		FROM transaction_detail_modifiers m
+		JOIN transaction_details td ON td.id = m.detail_id
+		WHERE td.transaction_id = $1
+		ORDER BY m.id`, transactionID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
 
-	return &models.Transaction{
-		ID:          transactionID,
-		TotalAmount: totalAmount,
-		CreatedAt:   createdAt,
-		Details:     details,
-	}, nil
+	lines := make(map[int]*models.TransactionDetail, len(details))
+	for i := range details {
+		lines[details[i].ID] = &details[i]
+	}
+	for rows.Next() {
+		var detailID int
+		var m models.TransactionDetailModifier
+		if err := rows.Scan(&detailID, &m.ModifierID, &m.GroupName, &m.Name, &m.PriceDelta); err != nil {
+			return err
+		}
+		if d, ok := lines[detailID]; ok {
+			d.Modifiers = append(d.Modifiers, m)
+		}
+	}
+	return rows.Err()
 }
 
-func (repo *TransactionRepository) GetSalesSummary(startDate, endDate time.Time) (*models.SalesSummary, error) {
+// loadComponents fills in the contents of the bundle lines among details.
+func (repo *TransactionRepository) loadComponents(ctx context.Context, transactionID int, details []models.TransactionDetail) error {
+	rows, err := repo.db.QueryContext(ctx, `
+		SELECT c.detail_id, c.product_id, c.product_name, c.choice_group_id, c.quantity, c.revenue, c.cost
+		FROM transaction_detail_components c
+		JOIN transaction_details td ON td.id = c.detail_id
+		WHERE td.transaction_id = $1
+		ORDER BY c.id`, transactionID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	lines := make(map[int]*models.TransactionDetail, len(details))
+	for i := range details {
+		lines[details[i].ID] = &details[i]
+	}
+	for rows.Next() {
+		var detailID int
+		var c models.TransactionDetailComponent
+		err := rows.Scan(&detailID, &c.ProductID, &c.ProductName, &c.ChoiceGroupID, &c.Quantity, &c.Revenue, &c.Cost)
+		if err != nil {
+			return err
+		}
+		if d, ok := lines[detailID]; ok {
+			d.Components = append(d.Components, c)
+		}
+	}
+	return rows.Err()
+}
+
+// ChangeStatus voids or refunds a completed transaction and puts the sold stock back.
+// shiftID is the shift paying the money back, if any.
+func (repo *TransactionRepository) ChangeStatus(ctx context.Context, id int, status string, attr models.Attribution, shiftID *int, reason string) (*models.Transaction, error) {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var t models.Transaction
+	err = tx.QueryRowContext(ctx, `
+		SELECT id, total_amount, cashier_id, terminal_id, store_id, payment_method, shift_id,
+		       customer_id, voucher_id, voucher_code, voucher_discount,
+		       points_redeemed, points_discount, points_earned, status, created_at
+		FROM transactions
+		WHERE id = $1
+		FOR UPDATE`, id).Scan(&t.ID, &t.TotalAmount, &t.CashierID, &t.TerminalID, &t.StoreID,
+		&t.PaymentMethod, &t.ShiftID, &t.CustomerID, &t.VoucherID, &t.VoucherCode, &t.VoucherDiscount,
+		&t.PointsRedeemed, &t.PointsDiscount, &t.PointsEarned,
+		&t.Status, &t.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("transaction not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if t.Status != models.TransactionStatusCompleted {
+		slog.DebugContext(ctx, "status change refused", slog.Int("transaction_id", id), slog.String("status", t.Status))
+		return nil, apperror.Conflict("transaction is already %s", t.Status)
+	}
+
+	// Bundle lines put back their components, not the bundle itself
+	_, err = tx.ExecContext(ctx, `
+		UPDATE products p
+		SET stock = p.stock + sold.quantity
+		FROM (
+			SELECT product_id, SUM(quantity) AS quantity
+			FROM (
+				SELECT td.product_id, td.quantity
+				FROM transaction_details td
+				WHERE td.transaction_id = $1
+				  AND NOT EXISTS (SELECT 1 FROM transaction_detail_components c WHERE c.detail_id = td.id)
+				UNION ALL
+				SELECT c.product_id, c.quantity
+				FROM transaction_detail_components c
+				JOIN transaction_details td ON td.id = c.detail_id
+				WHERE td.transaction_id = $1
+			) lines
+			GROUP BY product_id
+		) sold
+		WHERE p.id = sold.product_id`, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Give back redeemed points and take back earned ones; the balance may go
+	// negative when earned points were already spent
+	if t.CustomerID != nil && t.PointsRedeemed != t.PointsEarned {
+		err := addLoyaltyEntry(ctx, tx, *t.CustomerID, id, models.LoyaltyEntryReversal, t.PointsRedeemed-t.PointsEarned)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if t.VoucherID != nil {
+		if err := releaseVoucher(ctx, tx, *t.VoucherID, id); err != nil {
+			return nil, err
+		}
+	}
+
+	var changedAt time.Time
+	err = tx.QueryRowContext(ctx, `
+		UPDATE transactions
+		SET status = $1, status_changed_at = NOW(), status_changed_by = $2, status_changed_terminal = $3,
+		    status_reason = $4, status_shift_id = $5
+		WHERE id = $6
+		RETURNING status_changed_at`, status, attr.CashierID, attr.TerminalID, reason, shiftID, id).Scan(&changedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	before := transactionRecord(&t)
+	t.Status = status
+	t.StatusChangedAt = &changedAt
+	t.StatusChangedBy = attr.CashierID
+	t.StatusReason = reason
+
+	action := models.AuditActionVoid
+	if status == models.TransactionStatusRefunded {
+		action = models.AuditActionRefund
+	}
+	after := transactionRecord(&t)
+	after["status_reason"] = reason
+	if err := recordAudit(ctx, tx, models.AuditEntityTransaction, id, action, before, after); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// transactionRecord is the audited state of a transaction; lines are only
+// included when loaded.
+func transactionRecord(t *models.Transaction) audit.Record {
+	record := audit.Record{
+		"total_amount":   t.TotalAmount,
+		"payment_method": t.PaymentMethod,
+		"status":         t.Status,
+		"cashier_id":     t.CashierID,
+		"terminal_id":    t.TerminalID,
+		"store_id":       t.StoreID,
+	}
+	if t.CustomerID != nil {
+		record["customer_id"] = *t.CustomerID
+		record["points_redeemed"] = t.PointsRedeemed
+		record["points_earned"] = t.PointsEarned
+	}
+	if t.VoucherID != nil {
+		record["voucher_code"] = t.VoucherCode
+		record["voucher_discount"] = t.VoucherDiscount
+	}
+	if len(t.Details) > 0 {
+		lines := make([]audit.Record, 0, len(t.Details))
+		for _, d := range t.Details {
+			line := audit.Record{
+				"product_id": d.ProductID,
+				"unit_price": d.UnitPrice,
+				"quantity":   d.Quantity,
+				"discount":   d.Discount,
+				"subtotal":   d.Subtotal,
+			}
+			if d.PriceRuleID != nil {
+				line["price_rule_id"] = *d.PriceRuleID
+			}
+			if len(d.Modifiers) > 0 {
+				ids := make([]int, 0, len(d.Modifiers))
+				for _, m := range d.Modifiers {
+					ids = append(ids, m.ModifierID)
+				}
+				line["modifiers"] = ids
+			}
+			lines = append(lines, line)
+		}
+		record["details"] = lines
+	}
+	return record
+}
+
+func (repo *TransactionRepository) GetSalesSummary(ctx context.Context, startDate, endDate time.Time) (*models.SalesSummary, error) {
 	summary := &models.SalesSummary{}
 
 	queryTotals := `
 		SELECT COALESCE(SUM(total_amount), 0), COUNT(id)
 		FROM transactions
-		WHERE created_at >= $1 AND created_at <= $2
+		WHERE created_at >= $1 AND created_at <= $2 AND status = 'completed'
 	`
-	err := repo.db.QueryRow(queryTotals, startDate, endDate).Scan(&summary.TotalRevenue, &summary.TotalTransaction)
+	err := repo.db.QueryRowContext(ctx, queryTotals, startDate, endDate).Scan(&summary.TotalRevenue, &summary.TotalTransaction)
 	if err != nil {
 		return nil, err
 	}
 
 	queryBestSeller := `
-		SELECT p.name, COALESCE(SUM(td.quantity), 0) as total_qty
+		SELECT td.product_name, COALESCE(SUM(td.quantity), 0) as total_qty
 		FROM transaction_details td
 		JOIN transactions t ON td.transaction_id = t.id
-		JOIN products p ON td.product_id = p.id
-		WHERE t.created_at >= $1 AND t.created_at <= $2
-		GROUP BY p.name
+		WHERE t.created_at >= $1 AND t.created_at <= $2 AND t.status = 'completed'
+		GROUP BY td.product_name
 		ORDER BY total_qty DESC
 		LIMIT 1
 	`
-	err = repo.db.QueryRow(queryBestSeller, startDate, endDate).Scan(&summary.BestSeller.Name, &summary.BestSeller.Sold)
+	err = repo.db.QueryRowContext(ctx, queryBestSeller, startDate, endDate).Scan(&summary.BestSeller.Name, &summary.BestSeller.Sold)
 
 	if err == sql.ErrNoRows {
 		summary.BestSeller = models.ProductBestSeller{Name: "-", Sold: 0}
@@ -120,3 +571,114 @@ func (repo *TransactionRepository) GetSalesSummary(startDate, endDate time.Time)
 
 	return summary, nil
 }
+
+// GetProductSales lists quantity and revenue per product of completed sales.
+// Bundle lines count towards their components with the revenue allocated to
+// them, and names are taken from the latest sale.
+func (repo *TransactionRepository) GetProductSales(ctx context.Context, startDate, endDate time.Time) ([]models.ProductSales, error) {
+	rows, err := repo.db.QueryContext(ctx, `
+		SELECT product_id, (ARRAY_AGG(product_name ORDER BY transaction_id DESC))[1], SUM(quantity), SUM(revenue)
+		FROM (
+			SELECT t.id AS transaction_id, td.product_id, td.product_name, td.quantity, td.subtotal AS revenue
+			FROM transaction_details td
+			JOIN transactions t ON td.transaction_id = t.id
+			WHERE t.created_at >= $1 AND t.created_at <= $2 AND t.status = 'completed'
+			  AND NOT EXISTS (SELECT 1 FROM transaction_detail_components c WHERE c.detail_id = td.id)
+			UNION ALL
+			SELECT t.id, c.product_id, c.product_name, c.quantity, c.revenue
+			FROM transaction_detail_components c
+			JOIN transaction_details td ON td.id = c.detail_id
+			JOIN transactions t ON td.transaction_id = t.id
+			WHERE t.created_at >= $1 AND t.created_at <= $2 AND t.status = 'completed'
+		) sold
+		GROUP BY product_id
+		ORDER BY 4 DESC, 3 DESC`, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sales := make([]models.ProductSales, 0)
+	for rows.Next() {
+		var p models.ProductSales
+		if err := rows.Scan(&p.ProductID, &p.Name, &p.Quantity, &p.Revenue); err != nil {
+			return nil, err
+		}
+		sales = append(sales, p)
+	}
+
+	return sales, rows.Err()
+}
+
+// statusChangeColumns maps a breakdown column to the column recording who
+// made a void or refund, or on which terminal.
+var statusChangeColumns = map[string]string{
+	"cashier_id":  "status_changed_by",
+	"terminal_id": "status_changed_terminal",
+}
+
+// GetSalesBreakdown aggregates sales, voids and refunds per cashier_id or terminal_id.
+// Sales count for whoever rang them up when they were made, whatever happened
+// to them later; voids and refunds count separately for whoever made them,
+// when they were made. storeID is optional and narrows the report to a
+// single store.
+func (repo *TransactionRepository) GetSalesBreakdown(ctx context.Context, groupBy string, startDate, endDate time.Time, storeID string) ([]models.SalesBreakdown, error) {
+	changedBy, ok := statusChangeColumns[groupBy]
+	if !ok {
+		return nil, fmt.Errorf("unsupported breakdown %q", groupBy)
+	}
+
+	query := `
+		SELECT key,
+		       COUNT(*) FILTER (WHERE event = 'sale'),
+		       COALESCE(SUM(total_amount) FILTER (WHERE event = 'sale'), 0),
+		       COUNT(*) FILTER (WHERE event = 'voided'),
+		       COALESCE(SUM(total_amount) FILTER (WHERE event = 'voided'), 0),
+		       COUNT(*) FILTER (WHERE event = 'refunded'),
+		       COALESCE(SUM(total_amount) FILTER (WHERE event = 'refunded'), 0)
+		FROM (
+			SELECT ` + groupBy + ` AS key, 'sale' AS event, total_amount, store_id
+			FROM transactions
+			WHERE created_at >= $1 AND created_at <= $2
+			UNION ALL
+			SELECT ` + changedBy + `, status, total_amount, store_id
+			FROM transactions
+			WHERE status IN ('voided', 'refunded') AND status_changed_at >= $1 AND status_changed_at <= $2
+		) events`
+	args := []interface{}{startDate, endDate}
+
+	if storeID != "" {
+		query += " WHERE store_id = $3"
+		args = append(args, storeID)
+	}
+	query += " GROUP BY key ORDER BY 3 DESC"
+
+	rows, err := repo.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	breakdown := make([]models.SalesBreakdown, 0)
+	for rows.Next() {
+		var key string
+		var b models.SalesBreakdown
+		err := rows.Scan(&key, &b.TotalTransaction, &b.TotalRevenue,
+			&b.TotalVoid, &b.VoidAmount, &b.TotalRefund, &b.RefundAmount)
+		if err != nil {
+			return nil, err
+		}
+
+		if groupBy == "cashier_id" {
+			b.CashierID = key
+		} else {
+			b.TerminalID = key
+		}
+		if b.TotalTransaction > 0 {
+			b.AverageBasket = b.TotalRevenue / b.TotalTransaction
+		}
+		breakdown = append(breakdown, b)
+	}
+
+	return breakdown, rows.Err()
+}
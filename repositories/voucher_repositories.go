@@ -0,0 +1,279 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"kasir-api/apperror"
+	"kasir-api/audit"
+	"kasir-api/models"
+	"time"
+)
+
+type VoucherRepository struct {
+	db *sql.DB
+}
+
+func NewVoucherRepository(db *sql.DB) *VoucherRepository {
+	return &VoucherRepository{db: db}
+}
+
+const voucherColumns = `id, code, name, type, value, max_discount, min_spend, max_uses, per_customer_limit,
+	expires_at, uses, created_at, deleted_at`
+
+func scanVoucher(row interface{ Scan(...interface{}) error }) (*models.Voucher, error) {
+	var v models.Voucher
+	err := row.Scan(&v.ID, &v.Code, &v.Name, &v.Type, &v.Value, &v.MaxDiscount, &v.MinSpend, &v.MaxUses,
+		&v.PerCustomerLimit, &v.ExpiresAt, &v.Uses, &v.CreatedAt, &v.DeletedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// GetAll lists vouchers; deleted ones only when includeDeleted is set.
+func (repo *VoucherRepository) GetAll(ctx context.Context, includeDeleted bool) ([]models.Voucher, error) {
+	query := "SELECT " + voucherColumns + " FROM vouchers"
+	if !includeDeleted {
+		query += " WHERE deleted_at IS NULL"
+	}
+	query += " ORDER BY id"
+
+	rows, err := repo.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	vouchers := make([]models.Voucher, 0)
+	for rows.Next() {
+		v, err := scanVoucher(rows)
+		if err != nil {
+			return nil, err
+		}
+		vouchers = append(vouchers, *v)
+	}
+
+	return vouchers, rows.Err()
+}
+
+// GetByID returns the voucher; a deleted one is not found unless includeDeleted is set.
+func (repo *VoucherRepository) GetByID(ctx context.Context, id int, includeDeleted bool) (*models.Voucher, error) {
+	query := "SELECT " + voucherColumns + " FROM vouchers WHERE id = $1"
+	if !includeDeleted {
+		query += " AND deleted_at IS NULL"
+	}
+
+	v, err := scanVoucher(repo.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("voucher not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func (repo *VoucherRepository) Create(ctx context.Context, voucher *models.Voucher) error {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	created, err := scanVoucher(tx.QueryRowContext(ctx, `
+		INSERT INTO vouchers (code, name, type, value, max_discount, min_spend, max_uses, per_customer_limit, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING `+voucherColumns,
+		voucher.Code, voucher.Name, voucher.Type, voucher.Value, voucher.MaxDiscount, voucher.MinSpend,
+		voucher.MaxUses, voucher.PerCustomerLimit, voucher.ExpiresAt))
+	if err != nil {
+		return mapDBError(err, "voucher code is already in use")
+	}
+
+	err = recordAudit(ctx, tx, models.AuditEntityVoucher, created.ID, models.AuditActionCreate, nil, voucherRecord(created))
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	*voucher = *created
+	return nil
+}
+
+// Update replaces every field of the voucher but its use count.
+func (repo *VoucherRepository) Update(ctx context.Context, voucher *models.Voucher) error {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := lockVoucher(ctx, tx, "id = $1", voucher.ID)
+	if err != nil {
+		return err
+	}
+	if before == nil {
+		return apperror.NotFound("voucher not found")
+	}
+
+	updated, err := scanVoucher(tx.QueryRowContext(ctx, `
+		UPDATE vouchers
+		SET code = $1, name = $2, type = $3, value = $4, max_discount = $5, min_spend = $6,
+		    max_uses = $7, per_customer_limit = $8, expires_at = $9
+		WHERE id = $10
+		RETURNING `+voucherColumns,
+		voucher.Code, voucher.Name, voucher.Type, voucher.Value, voucher.MaxDiscount, voucher.MinSpend,
+		voucher.MaxUses, voucher.PerCustomerLimit, voucher.ExpiresAt, voucher.ID))
+	if err != nil {
+		return mapDBError(err, "voucher code is already in use")
+	}
+
+	err = recordAudit(ctx, tx, models.AuditEntityVoucher, voucher.ID, models.AuditActionUpdate, voucherRecord(before), voucherRecord(updated))
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	*voucher = *updated
+	return nil
+}
+
+// Delete soft-deletes the voucher; its code can no longer be redeemed.
+func (repo *VoucherRepository) Delete(ctx context.Context, id int) error {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := lockVoucher(ctx, tx, "id = $1", id)
+	if err != nil {
+		return err
+	}
+	if before == nil {
+		return apperror.NotFound("voucher not found")
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE vouchers SET deleted_at = NOW() WHERE id = $1", id); err != nil {
+		return err
+	}
+
+	err = recordAudit(ctx, tx, models.AuditEntityVoucher, id, models.AuditActionDelete, voucherRecord(before), nil)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Redemptions lists the uses of a voucher, newest first.
+func (repo *VoucherRepository) Redemptions(ctx context.Context, voucherID int) ([]models.VoucherRedemption, error) {
+	rows, err := repo.db.QueryContext(ctx, `
+		SELECT id, transaction_id, customer_id, discount, created_at, reversed_at
+		FROM voucher_redemptions
+		WHERE voucher_id = $1
+		ORDER BY id DESC`, voucherID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	redemptions := make([]models.VoucherRedemption, 0)
+	for rows.Next() {
+		var r models.VoucherRedemption
+		if err := rows.Scan(&r.ID, &r.TransactionID, &r.CustomerID, &r.Discount, &r.CreatedAt, &r.ReversedAt); err != nil {
+			return nil, err
+		}
+		redemptions = append(redemptions, r)
+	}
+
+	return redemptions, rows.Err()
+}
+
+// lockVoucher reads the voucher matching where for a change within tx, or
+// returns nil when there is none. Deleted vouchers never match.
+func lockVoucher(ctx context.Context, tx *sql.Tx, where string, args ...any) (*models.Voucher, error) {
+	v, err := scanVoucher(tx.QueryRowContext(ctx,
+		"SELECT "+voucherColumns+" FROM vouchers WHERE "+where+" AND deleted_at IS NULL FOR UPDATE", args...))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return v, err
+}
+
+func voucherRecord(v *models.Voucher) audit.Record {
+	return audit.Record{
+		"code":               v.Code,
+		"name":               v.Name,
+		"type":               v.Type,
+		"value":              v.Value,
+		"max_discount":       v.MaxDiscount,
+		"min_spend":          v.MinSpend,
+		"max_uses":           v.MaxUses,
+		"per_customer_limit": v.PerCustomerLimit,
+		"expires_at":         v.ExpiresAt,
+	}
+}
+
+// redeemVoucher takes one use of the voucher with code for a checkout of
+// amount and returns the voucher with its discount. The voucher row stays
+// locked until tx ends, so concurrent checkouts cannot both take its last use.
+func redeemVoucher(ctx context.Context, tx *sql.Tx, code string, customerID *int, amount int) (*models.Voucher, int, error) {
+	voucher, err := lockVoucher(ctx, tx, "code = $1", code)
+	if err != nil {
+		return nil, 0, err
+	}
+	if voucher == nil {
+		return nil, 0, apperror.NotFound("voucher %s not found", code)
+	}
+
+	if voucher.ExpiresAt != nil && !voucher.ExpiresAt.After(time.Now()) {
+		return nil, 0, apperror.Conflict("voucher %s has expired", code)
+	}
+	if voucher.MaxUses != nil && voucher.Uses >= *voucher.MaxUses {
+		return nil, 0, apperror.Conflict("voucher %s has been used up", code)
+	}
+	if amount < voucher.MinSpend {
+		return nil, 0, apperror.Conflict("voucher %s needs a minimum spend of %d", code, voucher.MinSpend)
+	}
+
+	if voucher.PerCustomerLimit != nil {
+		if customerID == nil {
+			return nil, 0, apperror.Validation("customer_id", fmt.Sprintf("voucher %s is limited per customer and needs a customer_id", code))
+		}
+		var used int
+		err := tx.QueryRowContext(ctx, `
+			SELECT COUNT(*) FROM voucher_redemptions
+			WHERE voucher_id = $1 AND customer_id = $2 AND reversed_at IS NULL`, voucher.ID, *customerID).Scan(&used)
+		if err != nil {
+			return nil, 0, err
+		}
+		if used >= *voucher.PerCustomerLimit {
+			return nil, 0, apperror.Conflict("voucher %s was already used %d time(s) by this customer", code, used)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE vouchers SET uses = uses + 1 WHERE id = $1", voucher.ID); err != nil {
+		return nil, 0, err
+	}
+	voucher.Uses++
+	return voucher, voucher.Discount(amount), nil
+}
+
+// releaseVoucher gives back the voucher use of a voided or refunded transaction.
+func releaseVoucher(ctx context.Context, tx *sql.Tx, voucherID, transactionID int) error {
+	result, err := tx.ExecContext(ctx, `
+		UPDATE voucher_redemptions SET reversed_at = NOW()
+		WHERE voucher_id = $1 AND transaction_id = $2 AND reversed_at IS NULL`, voucherID, transactionID)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil || n == 0 {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "UPDATE vouchers SET uses = uses - $1 WHERE id = $2", n, voucherID)
+	return err
+}
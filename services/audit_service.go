@@ -0,0 +1,54 @@
+package services
+
+import (
+	"context"
+	"kasir-api/apperror"
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"kasir-api/tracing"
+	"kasir-api/validation"
+	"time"
+)
+
+const defaultAuditLimit = 100
+
+type AuditService struct {
+	repo *repositories.AuditRepository
+}
+
+func NewAuditService(repo *repositories.AuditRepository) *AuditService {
+	return &AuditService{repo: repo}
+}
+
+// List returns matching audit entries, newest first. start and end are
+// optional YYYY-MM-DD days; end is inclusive.
+func (s *AuditService) List(ctx context.Context, filter models.AuditFilter, start, end string) ([]models.AuditEntry, error) {
+	ctx, span := tracing.Start(ctx, "AuditService.List")
+	defer span.End()
+
+	if err := validation.AuditFilter(&filter).Err(); err != nil {
+		return nil, err
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultAuditLimit
+	}
+
+	layout := "2006-01-02"
+	if start != "" {
+		startDate, err := time.Parse(layout, start)
+		if err != nil {
+			return nil, apperror.Validation("start_date", "start_date must use the YYYY-MM-DD format")
+		}
+		filter.Start = &startDate
+	}
+	if end != "" {
+		endDate, err := time.Parse(layout, end)
+		if err != nil {
+			return nil, apperror.Validation("end_date", "end_date must use the YYYY-MM-DD format")
+		}
+		endDate = endDate.Add(23*time.Hour + 59*time.Minute + 59*time.Second)
+		filter.End = &endDate
+	}
+
+	return s.repo.List(ctx, filter)
+}
@@ -0,0 +1,258 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"kasir-api/apperror"
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"kasir-api/tracing"
+	"kasir-api/validation"
+	"log/slog"
+	"time"
+)
+
+type CartService struct {
+	repo               *repositories.CartRepository
+	productRepo        *repositories.ProductRepository
+	transactionService *TransactionService
+	reservationTTL     time.Duration
+}
+
+// reservationTTL is how long a reserving cart holds its stock after the last change.
+func NewCartService(repo *repositories.CartRepository, productRepo *repositories.ProductRepository,
+	transactionService *TransactionService, reservationTTL time.Duration) *CartService {
+	return &CartService{
+		repo:               repo,
+		productRepo:        productRepo,
+		transactionService: transactionService,
+		reservationTTL:     reservationTTL,
+	}
+}
+
+// now is the store local time carts are priced at, as checkout prices them.
+func (s *CartService) now() time.Time {
+	return time.Now().In(s.transactionService.location)
+}
+
+func (s *CartService) reservedUntil() *time.Time {
+	until := time.Now().Add(s.reservationTTL)
+	return &until
+}
+
+func (s *CartService) Create(ctx context.Context, req models.CreateCartRequest, attr models.Attribution) (*models.Cart, error) {
+	ctx, span := tracing.Start(ctx, "CartService.Create")
+	defer span.End()
+
+	if err := validation.CreateCart(&req).Err(); err != nil {
+		return nil, err
+	}
+
+	cart := &models.Cart{
+		CashierID:    attr.CashierID,
+		TerminalID:   attr.TerminalID,
+		StoreID:      attr.StoreID,
+		Note:         req.Note,
+		ReserveStock: req.ReserveStock,
+	}
+	if cart.ReserveStock {
+		cart.ReservedUntil = s.reservedUntil()
+	}
+
+	if err := s.repo.Create(ctx, cart); err != nil {
+		return nil, err
+	}
+	return cart, nil
+}
+
+func (s *CartService) GetByID(ctx context.Context, id int) (*models.Cart, error) {
+	ctx, span := tracing.Start(ctx, "CartService.GetByID")
+	defer span.End()
+
+	return s.repo.GetByID(ctx, id, s.now())
+}
+
+func (s *CartService) GetAll(ctx context.Context, status, terminalID string) ([]models.Cart, error) {
+	ctx, span := tracing.Start(ctx, "CartService.GetAll")
+	defer span.End()
+
+	return s.repo.GetAll(ctx, status, terminalID)
+}
+
+// AddItem adds quantity of a product to the cart, on top of what is already
+// there. Bundles with choice groups and products with a required modifier
+// group are refused: cart lines carry no picks, so they could not check out.
+func (s *CartService) AddItem(ctx context.Context, cartID int, req models.CartItemRequest) (*models.Cart, error) {
+	ctx, span := tracing.Start(ctx, "CartService.AddItem")
+	defer span.End()
+
+	if err := validation.CartItem(&req, false).Err(); err != nil {
+		return nil, err
+	}
+
+	cart, err := s.activeCart(ctx, cartID)
+	if err != nil {
+		return nil, err
+	}
+
+	quantity := req.Quantity
+	for _, item := range cart.Items {
+		if item.ProductID == req.ProductID {
+			quantity += item.Quantity
+		}
+	}
+
+	return s.setQuantity(ctx, cart, req.ProductID, quantity)
+}
+
+// UpdateItem replaces the quantity of a cart line; zero removes it.
+func (s *CartService) UpdateItem(ctx context.Context, cartID int, req models.CartItemRequest) (*models.Cart, error) {
+	ctx, span := tracing.Start(ctx, "CartService.UpdateItem")
+	defer span.End()
+
+	if err := validation.CartItem(&req, true).Err(); err != nil {
+		return nil, err
+	}
+
+	cart, err := s.activeCart(ctx, cartID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.setQuantity(ctx, cart, req.ProductID, req.Quantity)
+}
+
+func (s *CartService) RemoveItem(ctx context.Context, cartID, productID int) (*models.Cart, error) {
+	ctx, span := tracing.Start(ctx, "CartService.RemoveItem")
+	defer span.End()
+
+	return s.UpdateItem(ctx, cartID, models.CartItemRequest{ProductID: productID, Quantity: 0})
+}
+
+func (s *CartService) setQuantity(ctx context.Context, cart *models.Cart, productID, quantity int) (*models.Cart, error) {
+	if quantity > 0 {
+		product, err := s.productRepo.GetByID(ctx, productID, false)
+		if err != nil {
+			return nil, err
+		}
+		choice, err := s.repo.RequiredChoice(ctx, productID)
+		if err != nil {
+			return nil, err
+		}
+		if choice != "" {
+			return nil, apperror.Validation("product_id",
+				fmt.Sprintf("%s needs a choice for %s, which a cart cannot hold; sell it through checkout", product.Name, choice))
+		}
+
+		// stock reserved by other carts is not available, whether or not this cart reserves
+		reserved, err := s.repo.GetReservedQuantity(ctx, productID, cart.ID)
+		if err != nil {
+			return nil, err
+		}
+		if quantity > product.Stock-reserved {
+			return nil, apperror.InsufficientStock("insufficient stock for product %s", product.Name)
+		}
+	}
+
+	if err := s.repo.SetItemQuantity(ctx, cart.ID, productID, quantity); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Touch(ctx, cart.ID, s.reservedUntil()); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetByID(ctx, cart.ID, s.now())
+}
+
+func (s *CartService) activeCart(ctx context.Context, id int) (*models.Cart, error) {
+	cart, err := s.repo.GetByID(ctx, id, s.now())
+	if err != nil {
+		return nil, err
+	}
+	if cart.Status != models.CartStatusActive {
+		return nil, apperror.Conflict("cart is %s; resume it before changing its items", cart.Status)
+	}
+	return cart, nil
+}
+
+// Hold parks an active cart so the terminal can serve the next customer.
+func (s *CartService) Hold(ctx context.Context, id int) (*models.Cart, error) {
+	ctx, span := tracing.Start(ctx, "CartService.Hold")
+	defer span.End()
+
+	if err := s.repo.UpdateStatus(ctx, id, []string{models.CartStatusActive}, models.CartStatusHeld); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, id, s.now())
+}
+
+func (s *CartService) Resume(ctx context.Context, id int) (*models.Cart, error) {
+	ctx, span := tracing.Start(ctx, "CartService.Resume")
+	defer span.End()
+
+	if err := s.repo.UpdateStatus(ctx, id, []string{models.CartStatusHeld}, models.CartStatusActive); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Touch(ctx, id, s.reservedUntil()); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, id, s.now())
+}
+
+func (s *CartService) Cancel(ctx context.Context, id int) error {
+	ctx, span := tracing.Start(ctx, "CartService.Cancel")
+	defer span.End()
+
+	return s.repo.UpdateStatus(ctx, id, []string{models.CartStatusActive, models.CartStatusHeld}, models.CartStatusCancelled)
+}
+
+// Checkout turns the cart into a transaction through the regular checkout.
+// The cart is claimed first so it cannot be checked out twice, and is linked
+// to the transaction in the same commit that records the sale.
+func (s *CartService) Checkout(ctx context.Context, id int, req models.CartCheckoutRequest, attr models.Attribution) (*models.Transaction, error) {
+	ctx, span := tracing.Start(ctx, "CartService.Checkout")
+	defer span.End()
+
+	cart, err := s.repo.GetByID(ctx, id, s.now())
+	if err != nil {
+		return nil, err
+	}
+	if len(cart.Items) == 0 {
+		return nil, apperror.Conflict("cart is empty")
+	}
+	for _, item := range cart.Items {
+		if item.Unavailable == models.CartItemDeleted {
+			return nil, apperror.Conflict("%s is no longer sold; remove it from the cart", item.ProductName)
+		}
+	}
+
+	previous := cart.Status
+	err = s.repo.UpdateStatus(ctx, id, []string{models.CartStatusActive, models.CartStatusHeld}, models.CartStatusConverted)
+	if err != nil {
+		return nil, err
+	}
+
+	checkout := models.CheckoutRequest{
+		PaymentMethod: req.PaymentMethod,
+		CustomerID:    req.CustomerID,
+		RedeemPoints:  req.RedeemPoints,
+		VoucherCode:   req.VoucherCode,
+		CartID:        id,
+	}
+	for _, item := range cart.Items {
+		checkout.Items = append(checkout.Items, models.CheckoutItem{ProductID: item.ProductID, Quantity: item.Quantity})
+	}
+
+	transaction, err := s.transactionService.Checkout(ctx, checkout, attr, true)
+	if err != nil {
+		// release the claim even if the client went away
+		revertErr := s.repo.UpdateStatus(context.WithoutCancel(ctx), id, []string{models.CartStatusConverted}, previous)
+		if revertErr != nil {
+			slog.ErrorContext(ctx, "failed to release cart after checkout error", slog.Int("cart_id", id), slog.Any("error", revertErr))
+		}
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "cart converted", slog.Int("cart_id", id), slog.Int("transaction_id", transaction.ID))
+	return transaction, nil
+}
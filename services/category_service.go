@@ -1,9 +1,13 @@
 package services
 
 import (
-	"errors"
+	"context"
+	"kasir-api/apperror"
 	"kasir-api/models"
 	"kasir-api/repositories"
+	"kasir-api/tracing"
+	"kasir-api/validation"
+	"log/slog"
 )
 
 type CategoryService struct {
@@ -14,35 +18,59 @@ func NewCategoryService(repo *repositories.CategoryRepository) *CategoryService
 	return &CategoryService{repo: repo}
 }
 
-func (s *CategoryService) GetAll(name string) ([]models.Category, error) {
-	return s.repo.GetAll(name)
+func (s *CategoryService) GetAll(ctx context.Context, name string, includeDeleted bool) ([]models.Category, error) {
+	ctx, span := tracing.Start(ctx, "CategoryService.GetAll")
+	defer span.End()
+
+	return s.repo.GetAll(ctx, name, includeDeleted)
 }
 
-func (s *CategoryService) Create(category *models.Category) error {
-	isDuplicate, err := s.repo.Exists(category.Name, category.Description)
+func (s *CategoryService) Create(ctx context.Context, category *models.Category) error {
+	ctx, span := tracing.Start(ctx, "CategoryService.Create")
+	defer span.End()
+
+	if err := validation.CreateCategory(category).Err(); err != nil {
+		return err
+	}
+
+	isDuplicate, err := s.repo.Exists(ctx, category.Name, category.Description)
 	if err != nil {
 		return err
 	}
 	if isDuplicate {
-		return errors.New("a category with the same name and description already exists")
+		return apperror.Conflict("a category with the same name and description already exists")
 	}
 
-	return s.repo.Create(category)
+	if err := s.repo.Create(ctx, category); err != nil {
+		return err
+	}
+	category.Active = true
+	return nil
 }
 
-func (s *CategoryService) GetByID(id int) (*models.Category, error) {
-	return s.repo.GetByID(id)
+func (s *CategoryService) GetByID(ctx context.Context, id int, includeDeleted bool) (*models.Category, error) {
+	ctx, span := tracing.Start(ctx, "CategoryService.GetByID")
+	defer span.End()
+
+	return s.repo.GetByID(ctx, id, includeDeleted)
 }
 
-func (s *CategoryService) Update(category *models.Category) error {
-	existingCategory, err := s.repo.GetByID(category.ID)
+func (s *CategoryService) Update(ctx context.Context, category *models.Category) error {
+	ctx, span := tracing.Start(ctx, "CategoryService.Update")
+	defer span.End()
+
+	if err := validation.UpdateCategory(category).Err(); err != nil {
+		return err
+	}
+
+	existingCategory, err := s.repo.GetByID(ctx, category.ID, false)
 	if err != nil {
 		return err
 	}
 
 	if category.Name == existingCategory.Name &&
 		category.Description == existingCategory.Description {
-		return errors.New("no changes detected; the updated data is identical to the current data")
+		return apperror.Conflict("no changes detected; the updated data is identical to the current data")
 	}
 
 	if category.Name == "" {
@@ -52,9 +80,33 @@ func (s *CategoryService) Update(category *models.Category) error {
 		category.Description = existingCategory.Description
 	}
 
-	return s.repo.Update(category)
+	if err := s.repo.Update(ctx, category); err != nil {
+		return err
+	}
+	category.Active = true
+	return nil
 }
 
-func (s *CategoryService) Delete(id int) error {
-	return s.repo.Delete(id)
+func (s *CategoryService) Delete(ctx context.Context, id int) error {
+	ctx, span := tracing.Start(ctx, "CategoryService.Delete")
+	defer span.End()
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "category deleted", slog.Int("category_id", id))
+	return nil
+}
+
+func (s *CategoryService) Restore(ctx context.Context, id int) (*models.Category, error) {
+	ctx, span := tracing.Start(ctx, "CategoryService.Restore")
+	defer span.End()
+
+	if err := s.repo.Restore(ctx, id); err != nil {
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "category restored", slog.Int("category_id", id))
+	return s.repo.GetByID(ctx, id, false)
 }
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"context"
+	"kasir-api/apperror"
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"kasir-api/tracing"
+	"kasir-api/validation"
+	"log/slog"
+)
+
+const defaultPurchaseLimit = 50
+
+type CustomerService struct {
+	repo    *repositories.CustomerRepository
+	loyalty models.LoyaltyProgram
+}
+
+func NewCustomerService(repo *repositories.CustomerRepository, loyalty models.LoyaltyProgram) *CustomerService {
+	return &CustomerService{repo: repo, loyalty: loyalty}
+}
+
+func (s *CustomerService) GetAll(ctx context.Context, search string, includeDeleted bool) ([]models.Customer, error) {
+	ctx, span := tracing.Start(ctx, "CustomerService.GetAll")
+	defer span.End()
+
+	return s.repo.GetAll(ctx, search, includeDeleted)
+}
+
+func (s *CustomerService) GetByID(ctx context.Context, id int, includeDeleted bool) (*models.Customer, error) {
+	ctx, span := tracing.Start(ctx, "CustomerService.GetByID")
+	defer span.End()
+
+	return s.repo.GetByID(ctx, id, includeDeleted)
+}
+
+func (s *CustomerService) Create(ctx context.Context, customer *models.Customer) error {
+	ctx, span := tracing.Start(ctx, "CustomerService.Create")
+	defer span.End()
+
+	if err := validation.CreateCustomer(customer).Err(); err != nil {
+		return err
+	}
+	if err := s.repo.Create(ctx, customer); err != nil {
+		return err
+	}
+	customer.Active = true
+
+	slog.InfoContext(ctx, "customer created", slog.Int("customer_id", customer.ID), slog.String("member_code", customer.MemberCode))
+	return nil
+}
+
+func (s *CustomerService) Update(ctx context.Context, customer *models.Customer) error {
+	ctx, span := tracing.Start(ctx, "CustomerService.Update")
+	defer span.End()
+
+	if err := validation.UpdateCustomer(customer).Err(); err != nil {
+		return err
+	}
+
+	existing, err := s.repo.GetByID(ctx, customer.ID, false)
+	if err != nil {
+		return err
+	}
+	customer.CreditLimit = existing.CreditLimit // changed with SetCreditLimit
+
+	if customer.Name == "" {
+		customer.Name = existing.Name
+	}
+	if customer.Phone == "" {
+		customer.Phone = existing.Phone
+	}
+	if customer.Email == "" {
+		customer.Email = existing.Email
+	}
+	if customer.MemberCode == "" {
+		customer.MemberCode = existing.MemberCode
+	}
+	if customer.Name == existing.Name && customer.Phone == existing.Phone &&
+		customer.Email == existing.Email && customer.MemberCode == existing.MemberCode {
+		return apperror.Conflict("no changes detected; the updated data is identical to the current data")
+	}
+
+	if err := s.repo.Update(ctx, customer); err != nil {
+		return err
+	}
+	customer.Points = existing.Points
+	customer.Receivable = existing.Receivable
+	customer.CreatedAt = existing.CreatedAt
+	customer.Active = true
+
+	slog.InfoContext(ctx, "customer updated", slog.Int("customer_id", customer.ID))
+	return nil
+}
+
+func (s *CustomerService) Delete(ctx context.Context, id int) error {
+	ctx, span := tracing.Start(ctx, "CustomerService.Delete")
+	defer span.End()
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "customer deleted", slog.Int("customer_id", id))
+	return nil
+}
+
+// Points returns the customer's balance and every change to it.
+func (s *CustomerService) Points(ctx context.Context, id int) (*models.LoyaltyAccount, error) {
+	ctx, span := tracing.Start(ctx, "CustomerService.Points")
+	defer span.End()
+
+	customer, err := s.repo.GetByID(ctx, id, true)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := s.repo.Ledger(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.LoyaltyAccount{
+		CustomerID: id,
+		Balance:    customer.Points,
+		PointValue: s.loyalty.PointValue,
+		Entries:    entries,
+	}, nil
+}
+
+// Purchases returns the customer's transactions, newest first.
+func (s *CustomerService) Purchases(ctx context.Context, id, limit int) ([]models.CustomerPurchase, error) {
+	ctx, span := tracing.Start(ctx, "CustomerService.Purchases")
+	defer span.End()
+
+	if err := validation.PurchaseLimit(limit).Err(); err != nil {
+		return nil, err
+	}
+	if limit == 0 {
+		limit = defaultPurchaseLimit
+	}
+
+	if _, err := s.repo.GetByID(ctx, id, true); err != nil {
+		return nil, err
+	}
+	return s.repo.Purchases(ctx, id, limit)
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"kasir-api/tracing"
+	"kasir-api/validation"
+	"log/slog"
+)
+
+type ModifierService struct {
+	repo         *repositories.ModifierRepository
+	productRepo  *repositories.ProductRepository
+	categoryRepo *repositories.CategoryRepository
+}
+
+func NewModifierService(repo *repositories.ModifierRepository, productRepo *repositories.ProductRepository, categoryRepo *repositories.CategoryRepository) *ModifierService {
+	return &ModifierService{repo: repo, productRepo: productRepo, categoryRepo: categoryRepo}
+}
+
+func (s *ModifierService) validate(ctx context.Context, group *models.ModifierGroup) error {
+	v := validation.ModifierGroup(group)
+	if !v.Valid() {
+		return v.Err()
+	}
+	return checkTarget(ctx, s.productRepo, s.categoryRepo, group.ProductID, group.CategoryID)
+}
+
+func (s *ModifierService) GetAll(ctx context.Context, includeDeleted bool) ([]models.ModifierGroup, error) {
+	ctx, span := tracing.Start(ctx, "ModifierService.GetAll")
+	defer span.End()
+
+	return s.repo.GetAll(ctx, includeDeleted)
+}
+
+func (s *ModifierService) GetByID(ctx context.Context, id int, includeDeleted bool) (*models.ModifierGroup, error) {
+	ctx, span := tracing.Start(ctx, "ModifierService.GetByID")
+	defer span.End()
+
+	return s.repo.GetByID(ctx, id, includeDeleted)
+}
+
+// ForProduct lists the modifier groups a checkout line of the product can use.
+func (s *ModifierService) ForProduct(ctx context.Context, productID int) ([]models.ModifierGroup, error) {
+	ctx, span := tracing.Start(ctx, "ModifierService.ForProduct")
+	defer span.End()
+
+	if _, err := s.productRepo.GetByID(ctx, productID, false); err != nil {
+		return nil, err
+	}
+	return s.repo.ForProduct(ctx, productID)
+}
+
+func (s *ModifierService) Create(ctx context.Context, group *models.ModifierGroup) error {
+	ctx, span := tracing.Start(ctx, "ModifierService.Create")
+	defer span.End()
+
+	if err := s.validate(ctx, group); err != nil {
+		return err
+	}
+	if err := s.repo.Create(ctx, group); err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "modifier group created", slog.Int("modifier_group_id", group.ID), slog.Int("options", len(group.Options)))
+	return nil
+}
+
+func (s *ModifierService) Update(ctx context.Context, group *models.ModifierGroup) error {
+	ctx, span := tracing.Start(ctx, "ModifierService.Update")
+	defer span.End()
+
+	if err := s.validate(ctx, group); err != nil {
+		return err
+	}
+	if err := s.repo.Update(ctx, group); err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "modifier group updated", slog.Int("modifier_group_id", group.ID))
+	return nil
+}
+
+func (s *ModifierService) Delete(ctx context.Context, id int) error {
+	ctx, span := tracing.Start(ctx, "ModifierService.Delete")
+	defer span.End()
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "modifier group deleted", slog.Int("modifier_group_id", id))
+	return nil
+}
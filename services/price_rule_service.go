@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"kasir-api/apperror"
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"kasir-api/tracing"
+	"kasir-api/validation"
+	"log/slog"
+)
+
+type PriceRuleService struct {
+	repo         *repositories.PriceRuleRepository
+	productRepo  *repositories.ProductRepository
+	categoryRepo *repositories.CategoryRepository
+}
+
+func NewPriceRuleService(repo *repositories.PriceRuleRepository, productRepo *repositories.ProductRepository, categoryRepo *repositories.CategoryRepository) *PriceRuleService {
+	return &PriceRuleService{repo: repo, productRepo: productRepo, categoryRepo: categoryRepo}
+}
+
+// validate checks the rule and that the product or category it targets exists.
+func (s *PriceRuleService) validate(ctx context.Context, rule *models.PriceRule) error {
+	v := validation.PriceRule(rule)
+	if !v.Valid() {
+		return v.Err()
+	}
+	return checkTarget(ctx, s.productRepo, s.categoryRepo, rule.ProductID, rule.CategoryID)
+}
+
+// checkTarget fails validation unless the product, or else the category, a
+// rule or group is attached to exists.
+func checkTarget(ctx context.Context, productRepo *repositories.ProductRepository, categoryRepo *repositories.CategoryRepository, productID, categoryID *int) error {
+	var err error
+	if productID != nil {
+		_, err = productRepo.GetByID(ctx, *productID, false)
+		if apperror.Is(err, apperror.CodeNotFound) {
+			return apperror.Validation("product_id", "product does not exist")
+		}
+	} else {
+		_, err = categoryRepo.GetByID(ctx, *categoryID, false)
+		if apperror.Is(err, apperror.CodeNotFound) {
+			return apperror.Validation("category_id", "category does not exist")
+		}
+	}
+	return err
+}
+
+func (s *PriceRuleService) GetAll(ctx context.Context, includeDeleted bool) ([]models.PriceRule, error) {
+	ctx, span := tracing.Start(ctx, "PriceRuleService.GetAll")
+	defer span.End()
+
+	return s.repo.GetAll(ctx, includeDeleted)
+}
+
+func (s *PriceRuleService) GetByID(ctx context.Context, id int, includeDeleted bool) (*models.PriceRule, error) {
+	ctx, span := tracing.Start(ctx, "PriceRuleService.GetByID")
+	defer span.End()
+
+	return s.repo.GetByID(ctx, id, includeDeleted)
+}
+
+func (s *PriceRuleService) Create(ctx context.Context, rule *models.PriceRule) error {
+	ctx, span := tracing.Start(ctx, "PriceRuleService.Create")
+	defer span.End()
+
+	if err := s.validate(ctx, rule); err != nil {
+		return err
+	}
+	if err := s.repo.Create(ctx, rule); err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "price rule created", slog.Int("price_rule_id", rule.ID), slog.String("type", rule.Type))
+	return nil
+}
+
+func (s *PriceRuleService) Update(ctx context.Context, rule *models.PriceRule) error {
+	ctx, span := tracing.Start(ctx, "PriceRuleService.Update")
+	defer span.End()
+
+	if err := s.validate(ctx, rule); err != nil {
+		return err
+	}
+	if err := s.repo.Update(ctx, rule); err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "price rule updated", slog.Int("price_rule_id", rule.ID))
+	return nil
+}
+
+func (s *PriceRuleService) Delete(ctx context.Context, id int) error {
+	ctx, span := tracing.Start(ctx, "PriceRuleService.Delete")
+	defer span.End()
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "price rule deleted", slog.Int("price_rule_id", id))
+	return nil
+}
@@ -1,45 +1,76 @@
 package services
 
 import (
-	"errors"
+	"context"
+	"fmt"
+	"kasir-api/apperror"
 	"kasir-api/models"
 	"kasir-api/repositories"
+	"kasir-api/tracing"
+	"kasir-api/validation"
+	"log/slog"
+	"time"
 )
 
 type ProductService struct {
-	repo *repositories.ProductRepository
+	repo         *repositories.ProductRepository
+	categoryRepo *repositories.CategoryRepository
+	priceRepo    *repositories.PriceRepository
+	bundleRepo   *repositories.BundleRepository
 }
 
-func NewProductService(repo *repositories.ProductRepository) *ProductService {
-	return &ProductService{repo: repo}
+func NewProductService(repo *repositories.ProductRepository, categoryRepo *repositories.CategoryRepository, priceRepo *repositories.PriceRepository, bundleRepo *repositories.BundleRepository) *ProductService {
+	return &ProductService{repo: repo, categoryRepo: categoryRepo, priceRepo: priceRepo, bundleRepo: bundleRepo}
 }
 
-func (s *ProductService) GetAll(name string) ([]models.Product, error) {
-	return s.repo.GetAll(name)
+// checkCategory records a violation on field when categoryID does not reference an existing category.
+func (s *ProductService) checkCategory(ctx context.Context, v *validation.Validator, field string, categoryID int) error {
+	if categoryID <= 0 {
+		return nil
+	}
+
+	_, err := s.categoryRepo.GetByID(ctx, categoryID, false)
+	if apperror.Is(err, apperror.CodeNotFound) {
+		v.Check(false, field, "category does not exist")
+		return nil
+	}
+	return err
 }
 
-func (s *ProductService) Create(product *models.Product) error {
-	isDuplicate, err := s.repo.Exists(product.Name, product.Price, product.CategoryId)
-	if err != nil {
+func (s *ProductService) GetAll(ctx context.Context, name string, includeDeleted bool) ([]models.Product, error) {
+	ctx, span := tracing.Start(ctx, "ProductService.GetAll")
+	defer span.End()
+
+	return s.repo.GetAll(ctx, name, includeDeleted)
+}
+
+func (s *ProductService) Create(ctx context.Context, product *models.Product) error {
+	ctx, span := tracing.Start(ctx, "ProductService.Create")
+	defer span.End()
+
+	v := validation.CreateProduct(product)
+	if err := s.checkCategory(ctx, v, "category_id", product.CategoryId); err != nil {
 		return err
 	}
-	if isDuplicate {
-		return errors.New("a product with the same name, price, and category already exists")
+	if err := v.Err(); err != nil {
+		return err
 	}
 
-	if product.Price <= 0 {
-		return errors.New("price must be greater than zero")
+	isDuplicate, err := s.repo.Exists(ctx, product.Name, product.Price, product.CategoryId)
+	if err != nil {
+		return err
 	}
-	if product.Stock < 0 {
-		return errors.New("stock cannot be negative")
+	if isDuplicate {
+		return apperror.Conflict("a product with the same name, price, and category already exists")
 	}
 
-	err = s.repo.Create(product)
+	err = s.repo.Create(ctx, product)
 	if err != nil {
 		return err
 	}
+	slog.InfoContext(ctx, "product created", slog.Int("product_id", product.ID), slog.Int("price", product.Price))
 
-	fullData, err := s.repo.GetByID(product.ID)
+	fullData, err := s.repo.GetByID(ctx, product.ID, false)
 	if err == nil {
 		*product = *fullData
 	}
@@ -47,29 +78,55 @@ func (s *ProductService) Create(product *models.Product) error {
 	return nil
 }
 
-func (s *ProductService) GetByID(id int) (*models.Product, error) {
-	return s.repo.GetByID(id)
+func (s *ProductService) GetByID(ctx context.Context, id int, includeDeleted bool) (*models.Product, error) {
+	ctx, span := tracing.Start(ctx, "ProductService.GetByID")
+	defer span.End()
+
+	return s.repo.GetByID(ctx, id, includeDeleted)
 }
 
-func (s *ProductService) Update(product *models.Product) error {
-	existingProduct, err := s.repo.GetByID(product.ID)
+func (s *ProductService) Update(ctx context.Context, product *models.Product) error {
+	ctx, span := tracing.Start(ctx, "ProductService.Update")
+	defer span.End()
+
+	v := validation.UpdateProduct(product)
+	if err := s.checkCategory(ctx, v, "category_id", product.CategoryId); err != nil {
+		return err
+	}
+	if err := v.Err(); err != nil {
+		return err
+	}
+
+	existingProduct, err := s.repo.GetByID(ctx, product.ID, false)
 	if err != nil {
 		return err
 	}
 
+	if existingProduct.IsBundle && product.Stock != 0 && product.Stock != existingProduct.Stock {
+		return apperror.Validation("stock", "a bundle's stock comes from its components and cannot be set")
+	}
+
 	if product.Name == existingProduct.Name &&
+		product.SKU == existingProduct.SKU &&
 		product.Price == existingProduct.Price &&
+		product.Cost == existingProduct.Cost &&
 		product.Stock == existingProduct.Stock &&
 		product.CategoryId == existingProduct.CategoryId {
-		return errors.New("no changes detected; the updated data is identical to the current data")
+		return apperror.Conflict("no changes detected; the updated data is identical to the current data")
 	}
 
 	if product.Name == "" {
 		product.Name = existingProduct.Name
 	}
+	if product.SKU == "" {
+		product.SKU = existingProduct.SKU
+	}
 	if product.Price == 0 {
 		product.Price = existingProduct.Price
 	}
+	if product.Cost == 0 {
+		product.Cost = existingProduct.Cost
+	}
 	if product.Stock == 0 {
 		product.Stock = existingProduct.Stock
 	}
@@ -77,12 +134,14 @@ func (s *ProductService) Update(product *models.Product) error {
 		product.CategoryId = existingProduct.CategoryId
 	}
 
-	err = s.repo.Update(product)
+	err = s.repo.Update(ctx, product)
 	if err != nil {
 		return err
 	}
+	slog.InfoContext(ctx, "product updated", slog.Int("product_id", product.ID),
+		slog.Int("old_price", existingProduct.Price), slog.Int("price", product.Price))
 
-	fullData, err := s.repo.GetByID(product.ID)
+	fullData, err := s.repo.GetByID(ctx, product.ID, false)
 	if err == nil {
 		*product = *fullData
 	}
@@ -90,6 +149,144 @@ func (s *ProductService) Update(product *models.Product) error {
 	return nil
 }
 
-func (s *ProductService) Delete(id int) error {
-	return s.repo.Delete(id)
+func (s *ProductService) Delete(ctx context.Context, id int) error {
+	ctx, span := tracing.Start(ctx, "ProductService.Delete")
+	defer span.End()
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "product deleted", slog.Int("product_id", id))
+	return nil
+}
+
+func (s *ProductService) Restore(ctx context.Context, id int) (*models.Product, error) {
+	ctx, span := tracing.Start(ctx, "ProductService.Restore")
+	defer span.End()
+
+	if err := s.repo.Restore(ctx, id); err != nil {
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "product restored", slog.Int("product_id", id))
+	return s.repo.GetByID(ctx, id, false)
+}
+
+// GetPrices returns past, current and scheduled prices of the product,
+// including a deleted one.
+func (s *ProductService) GetPrices(ctx context.Context, id int) (*models.PriceHistory, error) {
+	ctx, span := tracing.Start(ctx, "ProductService.GetPrices")
+	defer span.End()
+
+	product, err := s.repo.GetByID(ctx, id, true)
+	if err != nil {
+		return nil, err
+	}
+
+	history, upcoming, err := s.priceRepo.List(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.PriceHistory{
+		ProductID:    id,
+		CurrentPrice: product.Price,
+		History:      history,
+		Upcoming:     upcoming,
+	}, nil
+}
+
+func (s *ProductService) SchedulePrice(ctx context.Context, id int, req models.SchedulePriceRequest) (*models.ProductPrice, error) {
+	ctx, span := tracing.Start(ctx, "ProductService.SchedulePrice")
+	defer span.End()
+
+	if err := validation.SchedulePrice(&req, time.Now()).Err(); err != nil {
+		return nil, err
+	}
+
+	price, err := s.priceRepo.Schedule(ctx, id, req.Price, req.EffectiveAt)
+	if err != nil {
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "price scheduled", slog.Int("product_id", id), slog.Int("price", price.Price),
+		slog.Time("effective_at", price.EffectiveAt))
+	return price, nil
+}
+
+func (s *ProductService) CancelPrice(ctx context.Context, id, priceID int) error {
+	ctx, span := tracing.Start(ctx, "ProductService.CancelPrice")
+	defer span.End()
+
+	if err := s.priceRepo.Cancel(ctx, id, priceID); err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "scheduled price cancelled", slog.Int("product_id", id), slog.Int("price_id", priceID))
+	return nil
+}
+
+func (s *ProductService) GetBundle(ctx context.Context, id int) (*models.Bundle, error) {
+	ctx, span := tracing.Start(ctx, "ProductService.GetBundle")
+	defer span.End()
+
+	return s.bundleRepo.Get(ctx, id)
+}
+
+// SetBundle makes the product a bundle of the given components and choice
+// groups. Components must be active products that are not bundles themselves,
+// and the product must not be a component of another bundle.
+func (s *ProductService) SetBundle(ctx context.Context, id int, req models.SetBundleRequest) (*models.Bundle, error) {
+	ctx, span := tracing.Start(ctx, "ProductService.SetBundle")
+	defer span.End()
+
+	v := validation.SetBundle(&req)
+	for i, c := range req.Components {
+		field := fmt.Sprintf("components[%d].product_id", i)
+		if c.ProductID == id {
+			v.Check(false, field, "a bundle cannot contain itself")
+			continue
+		}
+		if c.ProductID <= 0 {
+			continue
+		}
+		component, err := s.repo.GetByID(ctx, c.ProductID, false)
+		if apperror.Is(err, apperror.CodeNotFound) {
+			v.Check(false, field, "product does not exist")
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		v.Check(!component.IsBundle, field, "a bundle cannot contain another bundle")
+	}
+	for i, g := range req.ChoiceGroups {
+		if err := s.checkCategory(ctx, v, fmt.Sprintf("choice_groups[%d].category_id", i), g.CategoryID); err != nil {
+			return nil, err
+		}
+	}
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := s.bundleRepo.Set(ctx, id, req); err != nil {
+		return nil, err
+	}
+	slog.InfoContext(ctx, "bundle set", slog.Int("product_id", id),
+		slog.Int("components", len(req.Components)), slog.Int("choice_groups", len(req.ChoiceGroups)))
+
+	return s.bundleRepo.Get(ctx, id)
+}
+
+func (s *ProductService) RemoveBundle(ctx context.Context, id int) error {
+	ctx, span := tracing.Start(ctx, "ProductService.RemoveBundle")
+	defer span.End()
+
+	if err := s.bundleRepo.Remove(ctx, id); err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "bundle removed", slog.Int("product_id", id))
+	return nil
 }
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"fmt"
+	"kasir-api/models"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// receiptWidth fits the 32 columns of a 58 mm thermal printer.
+const receiptWidth = 32
+
+// formatReceipt renders the transaction as plain text for a receipt printer,
+// from the lines as they were sold.
+func formatReceipt(t *models.Transaction) string {
+	var b strings.Builder
+	rule := strings.Repeat("-", receiptWidth) + "\n"
+
+	b.WriteString(receiptRow(fmt.Sprintf("Receipt #%d", t.ID), t.CreatedAt.Format("02/01/06 15:04")))
+	if t.CashierID != "" {
+		b.WriteString("Cashier: " + t.CashierID + "\n")
+	}
+	if t.TerminalID != "" {
+		b.WriteString("Terminal: " + t.TerminalID + "\n")
+	}
+	b.WriteString(rule)
+
+	for _, d := range t.Details {
+		b.WriteString(d.ProductName + "\n")
+		for _, m := range d.Modifiers {
+			delta := ""
+			if m.PriceDelta != 0 {
+				delta = signedRupiah(m.PriceDelta)
+			}
+			b.WriteString(receiptRow("  + "+m.Name, delta))
+		}
+		for _, c := range d.Components {
+			b.WriteString(fmt.Sprintf("  - %s x%d\n", c.ProductName, c.Quantity))
+		}
+		if d.Note != "" {
+			b.WriteString("  Note: " + d.Note + "\n")
+		}
+		b.WriteString(receiptRow(fmt.Sprintf("  %d x %s", d.Quantity, rupiah(d.UnitPrice)), rupiah(d.UnitPrice*d.Quantity)))
+		if d.Discount != 0 {
+			b.WriteString(receiptRow("  Discount", signedRupiah(-d.Discount)))
+		}
+	}
+
+	b.WriteString(rule)
+	if t.VoucherDiscount != 0 || t.PointsDiscount != 0 {
+		b.WriteString(receiptRow("Subtotal", rupiah(t.TotalAmount+t.VoucherDiscount+t.PointsDiscount)))
+	}
+	if t.VoucherDiscount != 0 {
+		b.WriteString(receiptRow("Voucher "+t.VoucherCode, signedRupiah(-t.VoucherDiscount)))
+	}
+	if t.PointsDiscount != 0 {
+		b.WriteString(receiptRow(fmt.Sprintf("Points (%d)", t.PointsRedeemed), signedRupiah(-t.PointsDiscount)))
+	}
+	b.WriteString(receiptRow("TOTAL", rupiah(t.TotalAmount)))
+	b.WriteString(receiptRow("Payment", strings.ToUpper(t.PaymentMethod)))
+	if t.PointsEarned != 0 {
+		b.WriteString(receiptRow("Points earned", strconv.Itoa(t.PointsEarned)))
+	}
+	if t.Status != models.TransactionStatusCompleted {
+		b.WriteString("\n" + center("*** "+strings.ToUpper(t.Status)+" ***"))
+	}
+	return b.String()
+}
+
+// receiptRow puts left and right on one line, or right on its own line when
+// both do not fit.
+func receiptRow(left, right string) string {
+	if right == "" {
+		return left + "\n"
+	}
+	gap := receiptWidth - utf8.RuneCountInString(left) - utf8.RuneCountInString(right)
+	if gap < 1 {
+		return left + "\n" + strings.Repeat(" ", max(receiptWidth-utf8.RuneCountInString(right), 0)) + right + "\n"
+	}
+	return left + strings.Repeat(" ", gap) + right + "\n"
+}
+
+func center(s string) string {
+	pad := (receiptWidth - utf8.RuneCountInString(s)) / 2
+	return strings.Repeat(" ", max(pad, 0)) + s + "\n"
+}
+
+// rupiah formats an amount with dots between thousands, e.g. 15.000.
+func rupiah(amount int) string {
+	sign := ""
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	digits := strconv.Itoa(amount)
+	var b strings.Builder
+	for i, r := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(r)
+	}
+	return sign + b.String()
+}
+
+func signedRupiah(amount int) string {
+	if amount > 0 {
+		return "+" + rupiah(amount)
+	}
+	return rupiah(amount)
+}
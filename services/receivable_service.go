@@ -0,0 +1,139 @@
+package services
+
+import (
+	"context"
+	"kasir-api/apperror"
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"kasir-api/tracing"
+	"kasir-api/validation"
+	"log/slog"
+	"time"
+)
+
+type ReceivableService struct {
+	repo         *repositories.ReceivableRepository
+	customerRepo *repositories.CustomerRepository
+	shiftRepo    *repositories.ShiftRepository
+	location     *time.Location
+}
+
+// location is the store timezone the aging report counts days in.
+func NewReceivableService(repo *repositories.ReceivableRepository, customerRepo *repositories.CustomerRepository, shiftRepo *repositories.ShiftRepository, location *time.Location) *ReceivableService {
+	return &ReceivableService{repo: repo, customerRepo: customerRepo, shiftRepo: shiftRepo, location: location}
+}
+
+// Account returns what the customer owes, the credit left and every kasbon
+// sale and repayment.
+func (s *ReceivableService) Account(ctx context.Context, customerID int) (*models.ReceivableAccount, error) {
+	ctx, span := tracing.Start(ctx, "ReceivableService.Account")
+	defer span.End()
+
+	customer, err := s.customerRepo.GetByID(ctx, customerID, true)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := s.repo.Ledger(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.ReceivableAccount{
+		CustomerID:  customerID,
+		CreditLimit: customer.CreditLimit,
+		Balance:     customer.Receivable,
+		Available:   max(customer.CreditLimit-customer.Receivable, 0),
+		Entries:     entries,
+	}, nil
+}
+
+// Repay records a payment towards the customer's kasbon, on the open shift
+// of the requesting terminal so cash repayments are in the drawer count.
+func (s *ReceivableService) Repay(ctx context.Context, customerID int, req models.RepaymentRequest, attr models.Attribution) (*models.ReceivablePayment, error) {
+	ctx, span := tracing.Start(ctx, "ReceivableService.Repay")
+	defer span.End()
+
+	if req.PaymentMethod == "" {
+		req.PaymentMethod = models.PaymentMethodCash
+	}
+	if err := validation.Repayment(&req).Err(); err != nil {
+		return nil, err
+	}
+
+	payment := &models.ReceivablePayment{
+		CustomerID:    customerID,
+		Amount:        req.Amount,
+		PaymentMethod: req.PaymentMethod,
+		CashierID:     attr.CashierID,
+		Note:          req.Note,
+	}
+	if attr.TerminalID != "" {
+		shift, err := s.shiftRepo.GetOpenByTerminal(ctx, attr.TerminalID)
+		if err != nil {
+			return nil, err
+		}
+		if shift != nil {
+			payment.ShiftID = &shift.ID
+		}
+	}
+
+	if err := s.repo.AddPayment(ctx, payment); err != nil {
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "receivable repaid",
+		slog.Int("customer_id", customerID),
+		slog.Int("amount", payment.Amount),
+		slog.String("payment_method", payment.PaymentMethod),
+		slog.String("cashier_id", attr.CashierID))
+	return payment, nil
+}
+
+func (s *ReceivableService) SetCreditLimit(ctx context.Context, customerID int, req models.CreditLimitRequest) (*models.Customer, error) {
+	ctx, span := tracing.Start(ctx, "ReceivableService.SetCreditLimit")
+	defer span.End()
+
+	if err := validation.CreditLimit(&req).Err(); err != nil {
+		return nil, err
+	}
+	customer, err := s.customerRepo.SetCreditLimit(ctx, customerID, req.CreditLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "credit limit changed", slog.Int("customer_id", customerID), slog.Int("credit_limit", req.CreditLimit))
+	return customer, nil
+}
+
+// Aging reports what customers owe by age on asOf, a YYYY-MM-DD date that
+// defaults to today in the store timezone.
+func (s *ReceivableService) Aging(ctx context.Context, asOf string) (*models.AgingReport, error) {
+	ctx, span := tracing.Start(ctx, "ReceivableService.Aging")
+	defer span.End()
+
+	var date time.Time
+	if asOf == "" {
+		now := time.Now().In(s.location)
+		date = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, s.location)
+	} else {
+		var err error
+		date, err = time.ParseInLocation("2006-01-02", asOf, s.location)
+		if err != nil {
+			return nil, apperror.Validation("as_of", "as_of must use the YYYY-MM-DD format")
+		}
+	}
+
+	customers, err := s.repo.Aging(ctx, date)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.AgingReport{AsOf: date.Format("2006-01-02"), Customers: customers}
+	for _, c := range customers {
+		report.Current += c.Current
+		report.Days31To60 += c.Days31To60
+		report.Over60 += c.Over60
+		report.Total += c.Total
+	}
+	return report, nil
+}
@@ -0,0 +1,190 @@
+package services
+
+import (
+	"context"
+	"kasir-api/apperror"
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"kasir-api/tracing"
+	"kasir-api/validation"
+	"log/slog"
+)
+
+type ShiftService struct {
+	repo *repositories.ShiftRepository
+}
+
+func NewShiftService(repo *repositories.ShiftRepository) *ShiftService {
+	return &ShiftService{repo: repo}
+}
+
+func (s *ShiftService) Open(ctx context.Context, req models.OpenShiftRequest, attr models.Attribution) (*models.Shift, error) {
+	ctx, span := tracing.Start(ctx, "ShiftService.Open")
+	defer span.End()
+
+	if err := validation.OpenShift(&req, attr).Err(); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.repo.GetOpenByTerminal(ctx, attr.TerminalID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, apperror.Conflict("this terminal already has an open shift")
+	}
+
+	shift := &models.Shift{
+		CashierID:    attr.CashierID,
+		TerminalID:   attr.TerminalID,
+		StoreID:      attr.StoreID,
+		OpeningFloat: req.OpeningFloat,
+	}
+	if err := s.repo.Open(ctx, shift); err != nil {
+		return nil, err
+	}
+	slog.InfoContext(ctx, "shift opened", slog.Int("shift_id", shift.ID),
+		slog.String("terminal_id", shift.TerminalID), slog.Int("opening_float", shift.OpeningFloat))
+
+	return shift, nil
+}
+
+func (s *ShiftService) GetByID(ctx context.Context, id int) (*models.Shift, error) {
+	ctx, span := tracing.Start(ctx, "ShiftService.GetByID")
+	defer span.End()
+
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *ShiftService) GetCurrent(ctx context.Context, terminalID string) (*models.Shift, error) {
+	ctx, span := tracing.Start(ctx, "ShiftService.GetCurrent")
+	defer span.End()
+
+	shift, err := s.repo.GetOpenByTerminal(ctx, terminalID)
+	if err != nil {
+		return nil, err
+	}
+	if shift == nil {
+		return nil, apperror.NotFound("no open shift on this terminal")
+	}
+	return shift, nil
+}
+
+func (s *ShiftService) AddCashMovement(ctx context.Context, movement *models.CashMovement) error {
+	ctx, span := tracing.Start(ctx, "ShiftService.AddCashMovement")
+	defer span.End()
+
+	if err := validation.CashMovement(movement).Err(); err != nil {
+		return err
+	}
+
+	return s.repo.AddCashMovement(ctx, movement)
+}
+
+// Report builds the X report of a shift (or the Z report once it is closed).
+func (s *ShiftService) Report(ctx context.Context, id int) (*models.ShiftReport, error) {
+	ctx, span := tracing.Start(ctx, "ShiftService.Report")
+	defer span.End()
+
+	shift, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var counted map[string]int
+	if shift.Status == models.ShiftStatusClosed {
+		counted, err = s.repo.GetCounts(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return s.buildReport(ctx, shift, counted)
+}
+
+func (s *ShiftService) Close(ctx context.Context, id int, req models.CloseShiftRequest, attr models.Attribution) (*models.ShiftReport, error) {
+	ctx, span := tracing.Start(ctx, "ShiftService.Close")
+	defer span.End()
+
+	if err := validation.CloseShift(&req).Err(); err != nil {
+		return nil, err
+	}
+
+	shift, err := s.repo.Close(ctx, id, req.Counted, attr.CashierID)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := s.buildReport(ctx, shift, req.Counted)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range report.Tenders {
+		if t.Difference != nil && *t.Difference != 0 {
+			slog.WarnContext(ctx, "shift closed with a difference", slog.Int("shift_id", id),
+				slog.String("payment_method", t.PaymentMethod), slog.Int("difference", *t.Difference))
+		}
+	}
+	slog.InfoContext(ctx, "shift closed", slog.Int("shift_id", id))
+	return report, nil
+}
+
+func (s *ShiftService) buildReport(ctx context.Context, shift *models.Shift, counted map[string]int) (*models.ShiftReport, error) {
+	totals, err := s.repo.GetTenderTotals(ctx, shift.ID)
+	if err != nil {
+		return nil, err
+	}
+	payIns, payOuts, err := s.repo.GetCashMovementTotals(ctx, shift.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.ShiftReport{Type: "X", Shift: *shift}
+	if shift.Status == models.ShiftStatusClosed {
+		report.Type = "Z"
+	}
+
+	// cash is always reconciled because of the opening float
+	tenders := map[string]*models.TenderReconciliation{
+		models.PaymentMethodCash: {PaymentMethod: models.PaymentMethodCash},
+	}
+	order := []string{models.PaymentMethodCash}
+	for _, t := range totals {
+		if _, ok := tenders[t.PaymentMethod]; !ok {
+			tenders[t.PaymentMethod] = &models.TenderReconciliation{PaymentMethod: t.PaymentMethod}
+			order = append(order, t.PaymentMethod)
+		}
+		tenders[t.PaymentMethod].Sales = t.Sales
+		tenders[t.PaymentMethod].Refunds = t.Refunds
+		tenders[t.PaymentMethod].Repayments = t.Repayments
+		report.TotalTransaction += t.SalesCount
+		report.TotalRefund += t.RefundCount
+	}
+	for method := range counted {
+		if _, ok := tenders[method]; !ok {
+			tenders[method] = &models.TenderReconciliation{PaymentMethod: method}
+			order = append(order, method)
+		}
+	}
+
+	cash := tenders[models.PaymentMethodCash]
+	cash.PayIns = payIns
+	cash.PayOuts = payOuts
+
+	report.Tenders = make([]models.TenderReconciliation, 0, len(order))
+	for _, method := range order {
+		t := tenders[method]
+		t.Expected = t.Sales - t.Refunds + t.Repayments + t.PayIns - t.PayOuts
+		if method == models.PaymentMethodCash {
+			t.Expected += shift.OpeningFloat
+		}
+		if amount, ok := counted[method]; ok {
+			difference := amount - t.Expected
+			t.Counted = &amount
+			t.Difference = &difference
+		}
+		report.Tenders = append(report.Tenders, *t)
+	}
+
+	return report, nil
+}
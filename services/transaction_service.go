@@ -1,24 +1,221 @@
 package services
 
 import (
+	"context"
+	"kasir-api/apperror"
+	"kasir-api/metrics"
 	"kasir-api/models"
 	"kasir-api/repositories"
+	"kasir-api/tracing"
+	"kasir-api/validation"
+	"log/slog"
 	"time"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type TransactionService struct {
-	repo *repositories.TransactionRepository
+	repo             *repositories.TransactionRepository
+	shiftRepo        *repositories.ShiftRepository
+	requireOpenShift bool
+	location         *time.Location
+	loyalty          models.LoyaltyProgram
+}
+
+// When requireOpenShift is set, checkout is refused on terminals without an open shift.
+// location is the store timezone that time-based price rules are written in.
+// loyalty sets how customers earn and redeem points at checkout.
+func NewTransactionService(repo *repositories.TransactionRepository, shiftRepo *repositories.ShiftRepository, requireOpenShift bool, location *time.Location, loyalty models.LoyaltyProgram) *TransactionService {
+	return &TransactionService{repo: repo, shiftRepo: shiftRepo, requireOpenShift: requireOpenShift, location: location, loyalty: loyalty}
+}
+
+func (s *TransactionService) Checkout(ctx context.Context, req models.CheckoutRequest, attr models.Attribution, useLock bool) (*models.Transaction, error) {
+	ctx, span := tracing.Start(ctx, "TransactionService.Checkout",
+		attribute.Int("checkout.item_count", len(req.Items)),
+		attribute.String("checkout.payment_method", req.PaymentMethod),
+		attribute.String("pos.terminal_id", attr.TerminalID),
+	)
+
+	transaction, err := s.checkout(ctx, req, attr, useLock)
+	metrics.ObserveCheckout(transaction, err)
+	if err != nil {
+		span.SetAttributes(attribute.String("error.code", string(apperror.CodeOf(err))))
+	} else {
+		span.SetAttributes(
+			attribute.Int("transaction.id", transaction.ID),
+			attribute.Int("transaction.total_amount", transaction.TotalAmount),
+		)
+		slog.InfoContext(ctx, "checkout completed",
+			slog.Int("transaction_id", transaction.ID),
+			slog.Int("total_amount", transaction.TotalAmount),
+			slog.Int("items", len(transaction.Details)),
+			slog.String("cashier_id", attr.CashierID),
+			slog.String("terminal_id", attr.TerminalID))
+	}
+	tracing.End(span, err)
+	return transaction, err
+}
+
+func (s *TransactionService) checkout(ctx context.Context, req models.CheckoutRequest, attr models.Attribution, useLock bool) (*models.Transaction, error) {
+	req.VoucherCode = normalizeVoucherCode(req.VoucherCode)
+	if err := validation.Checkout(&req).Err(); err != nil {
+		return nil, err
+	}
+	if req.PaymentMethod == "" {
+		req.PaymentMethod = models.PaymentMethodCash
+	}
+	if req.RedeemPoints > 0 && s.loyalty.PointValue <= 0 {
+		return nil, apperror.Conflict("points cannot be redeemed")
+	}
+
+	shiftID, err := s.shiftFor(ctx, attr)
+	if err != nil {
+		return nil, err
+	}
+	req.ShiftID = shiftID
+	req.SoldAt = time.Now().In(s.location)
+	req.Loyalty = s.loyalty
+
+	return s.repo.CreateTransaction(ctx, req, attr, useLock)
+}
+
+func (s *TransactionService) GetByID(ctx context.Context, id int) (*models.Transaction, error) {
+	ctx, span := tracing.Start(ctx, "TransactionService.GetByID")
+	defer span.End()
+
+	return s.repo.GetByID(ctx, id)
+}
+
+// Receipt renders the transaction as printable text.
+func (s *TransactionService) Receipt(ctx context.Context, id int) (string, error) {
+	ctx, span := tracing.Start(ctx, "TransactionService.Receipt")
+	defer span.End()
+
+	transaction, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	return formatReceipt(transaction), nil
+}
+
+func (s *TransactionService) Void(ctx context.Context, id int, attr models.Attribution, reason string) (*models.Transaction, error) {
+	ctx, span := tracing.Start(ctx, "TransactionService.Void")
+	defer span.End()
+
+	if err := validation.StatusChange(&models.StatusChangeRequest{Reason: reason}).Err(); err != nil {
+		return nil, err
+	}
+	shiftID, err := s.shiftFor(ctx, attr)
+	if err != nil {
+		return nil, err
+	}
+	return s.changeStatus(ctx, id, models.TransactionStatusVoided, attr, shiftID, reason)
+}
+
+func (s *TransactionService) Refund(ctx context.Context, id int, attr models.Attribution, reason string) (*models.Transaction, error) {
+	ctx, span := tracing.Start(ctx, "TransactionService.Refund")
+	defer span.End()
+
+	if err := validation.StatusChange(&models.StatusChangeRequest{Reason: reason}).Err(); err != nil {
+		return nil, err
+	}
+	shiftID, err := s.shiftFor(ctx, attr)
+	if err != nil {
+		return nil, err
+	}
+	return s.changeStatus(ctx, id, models.TransactionStatusRefunded, attr, shiftID, reason)
+}
+
+func (s *TransactionService) changeStatus(ctx context.Context, id int, status string, attr models.Attribution, shiftID *int, reason string) (*models.Transaction, error) {
+	transaction, err := s.repo.ChangeStatus(ctx, id, status, attr, shiftID, reason)
+	if err != nil {
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "transaction "+status,
+		slog.Int("transaction_id", id),
+		slog.Int("total_amount", transaction.TotalAmount),
+		slog.String("cashier_id", attr.CashierID))
+	return transaction, nil
+}
+
+// shiftFor returns the open shift that money moved by the request is counted
+// in, refusing the request when there is none and an open shift is required.
+func (s *TransactionService) shiftFor(ctx context.Context, attr models.Attribution) (*int, error) {
+	shiftID, err := s.openShiftID(ctx, attr)
+	if err != nil {
+		return nil, err
+	}
+	if shiftID == nil && s.requireOpenShift {
+		return nil, apperror.Conflict("no open shift on this terminal")
+	}
+	return shiftID, nil
 }
 
-func NewTransactionService(repo *repositories.TransactionRepository) *TransactionService {
-	return &TransactionService{repo: repo}
+// openShiftID returns the open shift of the requesting terminal, or nil when there is none.
+func (s *TransactionService) openShiftID(ctx context.Context, attr models.Attribution) (*int, error) {
+	if attr.TerminalID == "" {
+		return nil, nil
+	}
+
+	shift, err := s.shiftRepo.GetOpenByTerminal(ctx, attr.TerminalID)
+	if err != nil || shift == nil {
+		return nil, err
+	}
+	return &shift.ID, nil
 }
 
-func (s *TransactionService) Checkout(items []models.CheckoutItem, useLock bool) (*models.Transaction, error) {
-	return s.repo.CreateTransaction(items, useLock)
+func (s *TransactionService) GetReport(ctx context.Context, start, end string) (*models.SalesSummary, error) {
+	ctx, span := tracing.Start(ctx, "TransactionService.GetReport")
+	defer span.End()
+
+	startDate, endDate, err := parseDateRange(start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetSalesSummary(ctx, startDate, endDate)
+}
+
+func (s *TransactionService) GetProductReport(ctx context.Context, start, end string) ([]models.ProductSales, error) {
+	ctx, span := tracing.Start(ctx, "TransactionService.GetProductReport")
+	defer span.End()
+
+	startDate, endDate, err := parseDateRange(start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetProductSales(ctx, startDate, endDate)
+}
+
+func (s *TransactionService) GetCashierReport(ctx context.Context, start, end, storeID string) ([]models.SalesBreakdown, error) {
+	ctx, span := tracing.Start(ctx, "TransactionService.GetCashierReport")
+	defer span.End()
+
+	startDate, endDate, err := parseDateRange(start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetSalesBreakdown(ctx, "cashier_id", startDate, endDate, storeID)
+}
+
+func (s *TransactionService) GetTerminalReport(ctx context.Context, start, end, storeID string) ([]models.SalesBreakdown, error) {
+	ctx, span := tracing.Start(ctx, "TransactionService.GetTerminalReport")
+	defer span.End()
+
+	startDate, endDate, err := parseDateRange(start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetSalesBreakdown(ctx, "terminal_id", startDate, endDate, storeID)
 }
 
-func (s *TransactionService) GetReport(start, end string) (*models.SalesSummary, error) {
+// parseDateRange turns YYYY-MM-DD query values into an inclusive day range,
+// defaulting to today when either side is missing.
+func parseDateRange(start, end string) (time.Time, time.Time, error) {
 	var startDate, endDate time.Time
 	var err error
 
@@ -30,15 +227,15 @@ func (s *TransactionService) GetReport(start, end string) (*models.SalesSummary,
 		layout := "2006-01-02"
 		startDate, err = time.Parse(layout, start)
 		if err != nil {
-			return nil, err
+			return time.Time{}, time.Time{}, apperror.Validation("start_date", "start_date must use the YYYY-MM-DD format")
 		}
 
 		endDate, err = time.Parse(layout, end)
 		if err != nil {
-			return nil, err
+			return time.Time{}, time.Time{}, apperror.Validation("end_date", "end_date must use the YYYY-MM-DD format")
 		}
 		endDate = endDate.Add(23*time.Hour + 59*time.Minute + 59*time.Second)
 	}
 
-	return s.repo.GetSalesSummary(startDate, endDate)
+	return startDate, endDate, nil
 }
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"kasir-api/apperror"
+	"kasir-api/database/dbtest"
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"kasir-api/tracing/tracingtest"
+
+	"go.opentelemetry.io/otel/codes"
+)
+
+// newTestTransactionService returns a checkout service on the test database
+// and a function adding a product to sell.
+func newTestTransactionService(t *testing.T) (*TransactionService, func(name string, price, stock int) *models.Product) {
+	t.Helper()
+
+	db := dbtest.Open(t)
+	productRepo := repositories.NewProductRepository(db)
+	categoryRepo := repositories.NewCategoryRepository(db)
+	products := NewProductService(productRepo, categoryRepo, repositories.NewPriceRepository(db), repositories.NewBundleRepository(db))
+	transactions := NewTransactionService(repositories.NewTransactionRepository(db), repositories.NewShiftRepository(db),
+		false, time.UTC, models.LoyaltyProgram{})
+
+	category := &models.Category{Name: "Menu"}
+	if err := NewCategoryService(categoryRepo).Create(context.Background(), category); err != nil {
+		t.Fatalf("create category: %v", err)
+	}
+	createProduct := func(name string, price, stock int) *models.Product {
+		t.Helper()
+		product := &models.Product{Name: name, Price: price, Stock: stock, CategoryId: category.ID}
+		if err := products.Create(context.Background(), product); err != nil {
+			t.Fatalf("create product: %v", err)
+		}
+		return product
+	}
+	return transactions, createProduct
+}
+
+func TestCheckoutSpan(t *testing.T) {
+	transactions, createProduct := newTestTransactionService(t)
+	ctx := context.Background()
+	product := createProduct("Kopi Susu", 18000, 10)
+
+	exporter := tracingtest.Record(t)
+	req := models.CheckoutRequest{Items: []models.CheckoutItem{{ProductID: product.ID, Quantity: 2}}}
+	transaction, err := transactions.Checkout(ctx, req, models.Attribution{CashierID: "kasir-1", TerminalID: "T1"}, false)
+	if err != nil {
+		t.Fatalf("checkout: %v", err)
+	}
+
+	span := tracingtest.Find(t, exporter, "TransactionService.Checkout")
+	if got := tracingtest.Attribute(span, "checkout.item_count"); got != int64(1) {
+		t.Errorf("checkout.item_count = %v, want 1", got)
+	}
+	if got := tracingtest.Attribute(span, "transaction.id"); got != int64(transaction.ID) {
+		t.Errorf("transaction.id = %v, want %d", got, transaction.ID)
+	}
+	if got := tracingtest.Attribute(span, "transaction.total_amount"); got != int64(36000) {
+		t.Errorf("transaction.total_amount = %v, want 36000", got)
+	}
+	if got := tracingtest.Attribute(span, "error.code"); got != nil {
+		t.Errorf("error.code = %v, want unset", got)
+	}
+
+	insert := tracingtest.Find(t, exporter, "db INSERT")
+	if insert.SpanContext.TraceID() != span.SpanContext.TraceID() {
+		t.Errorf("db INSERT is not in the checkout trace")
+	}
+}
+
+func TestCheckoutSpanRecordsErrorCode(t *testing.T) {
+	exporter := tracingtest.Record(t)
+	// validation fails before the repositories are used
+	transactions := NewTransactionService(repositories.NewTransactionRepository(nil), repositories.NewShiftRepository(nil),
+		false, time.UTC, models.LoyaltyProgram{})
+
+	_, err := transactions.Checkout(context.Background(), models.CheckoutRequest{}, models.Attribution{}, false)
+	if !apperror.Is(err, apperror.CodeValidation) {
+		t.Fatalf("err = %v, want a validation error", err)
+	}
+
+	span := tracingtest.Find(t, exporter, "TransactionService.Checkout")
+	if got := tracingtest.Attribute(span, "checkout.item_count"); got != int64(0) {
+		t.Errorf("checkout.item_count = %v, want 0", got)
+	}
+	if got := tracingtest.Attribute(span, "error.code"); got != string(apperror.CodeValidation) {
+		t.Errorf("error.code = %v, want %s", got, apperror.CodeValidation)
+	}
+	if got := tracingtest.Attribute(span, "transaction.id"); got != nil {
+		t.Errorf("transaction.id = %v, want unset", got)
+	}
+	if span.Status.Code != codes.Error {
+		t.Errorf("status = %v, want error", span.Status.Code)
+	}
+}
+
+func TestCheckoutSpanRecordsStockError(t *testing.T) {
+	transactions, createProduct := newTestTransactionService(t)
+	ctx := context.Background()
+	product := createProduct("Roti Bakar", 15000, 1)
+
+	exporter := tracingtest.Record(t)
+	req := models.CheckoutRequest{Items: []models.CheckoutItem{{ProductID: product.ID, Quantity: 5}}}
+	if _, err := transactions.Checkout(ctx, req, models.Attribution{}, false); err == nil {
+		t.Fatal("checkout succeeded, want insufficient stock")
+	}
+
+	span := tracingtest.Find(t, exporter, "TransactionService.Checkout")
+	if got := tracingtest.Attribute(span, "error.code"); got != string(apperror.CodeInsufficientStock) {
+		t.Errorf("error.code = %v, want %s", got, apperror.CodeInsufficientStock)
+	}
+}
+
+func TestVoidAndRefundRequireOpenShift(t *testing.T) {
+	// a request without a terminal has no open shift; nothing is looked up
+	transactions := NewTransactionService(repositories.NewTransactionRepository(nil), repositories.NewShiftRepository(nil),
+		true, time.UTC, models.LoyaltyProgram{})
+	ctx := context.Background()
+	attr := models.Attribution{CashierID: "kasir-1"}
+
+	if _, err := transactions.Void(ctx, 1, attr, "wrong item"); !apperror.Is(err, apperror.CodeConflict) {
+		t.Errorf("void: err = %v, want a conflict", err)
+	}
+	if _, err := transactions.Refund(ctx, 1, attr, "returned"); !apperror.Is(err, apperror.CodeConflict) {
+		t.Errorf("refund: err = %v, want a conflict", err)
+	}
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"kasir-api/tracing"
+	"kasir-api/validation"
+	"log/slog"
+	"strings"
+)
+
+// Generated codes leave out characters that are easily misread on print: 0/O, 1/I.
+const (
+	voucherCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	voucherCodeLength   = 8
+)
+
+type VoucherService struct {
+	repo *repositories.VoucherRepository
+}
+
+func NewVoucherService(repo *repositories.VoucherRepository) *VoucherService {
+	return &VoucherService{repo: repo}
+}
+
+func (s *VoucherService) GetAll(ctx context.Context, includeDeleted bool) ([]models.Voucher, error) {
+	ctx, span := tracing.Start(ctx, "VoucherService.GetAll")
+	defer span.End()
+
+	return s.repo.GetAll(ctx, includeDeleted)
+}
+
+func (s *VoucherService) GetByID(ctx context.Context, id int, includeDeleted bool) (*models.Voucher, error) {
+	ctx, span := tracing.Start(ctx, "VoucherService.GetByID")
+	defer span.End()
+
+	return s.repo.GetByID(ctx, id, includeDeleted)
+}
+
+// Create adds the voucher, generating a printable code when none is given.
+func (s *VoucherService) Create(ctx context.Context, voucher *models.Voucher) error {
+	ctx, span := tracing.Start(ctx, "VoucherService.Create")
+	defer span.End()
+
+	voucher.Code = normalizeVoucherCode(voucher.Code)
+	if voucher.Code == "" {
+		code, err := generateVoucherCode()
+		if err != nil {
+			return err
+		}
+		voucher.Code = code
+	}
+	if err := validation.Voucher(voucher).Err(); err != nil {
+		return err
+	}
+	if err := s.repo.Create(ctx, voucher); err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "voucher created", slog.Int("voucher_id", voucher.ID), slog.String("code", voucher.Code))
+	return nil
+}
+
+func (s *VoucherService) Update(ctx context.Context, voucher *models.Voucher) error {
+	ctx, span := tracing.Start(ctx, "VoucherService.Update")
+	defer span.End()
+
+	voucher.Code = normalizeVoucherCode(voucher.Code)
+	if err := validation.Voucher(voucher).Err(); err != nil {
+		return err
+	}
+	if err := s.repo.Update(ctx, voucher); err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "voucher updated", slog.Int("voucher_id", voucher.ID))
+	return nil
+}
+
+func (s *VoucherService) Delete(ctx context.Context, id int) error {
+	ctx, span := tracing.Start(ctx, "VoucherService.Delete")
+	defer span.End()
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "voucher deleted", slog.Int("voucher_id", id))
+	return nil
+}
+
+// Redemptions lists the uses of the voucher, newest first.
+func (s *VoucherService) Redemptions(ctx context.Context, id int) ([]models.VoucherRedemption, error) {
+	ctx, span := tracing.Start(ctx, "VoucherService.Redemptions")
+	defer span.End()
+
+	if _, err := s.repo.GetByID(ctx, id, true); err != nil {
+		return nil, err
+	}
+	return s.repo.Redemptions(ctx, id)
+}
+
+// normalizeVoucherCode makes codes case-insensitive as typed at the till.
+func normalizeVoucherCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func generateVoucherCode() (string, error) {
+	random := make([]byte, voucherCodeLength)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	code := make([]byte, voucherCodeLength)
+	for i, b := range random {
+		code[i] = voucherCodeAlphabet[int(b)%len(voucherCodeAlphabet)]
+	}
+	return string(code), nil
+}
@@ -0,0 +1,382 @@
+package validation
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"kasir-api/models"
+)
+
+const (
+	maxNameLength        = 100
+	maxSKULength         = 64
+	maxDescriptionLength = 255
+	maxReasonLength      = 255
+	maxPhoneLength       = 32
+	maxEmailLength       = 255
+	maxMemberCodeLength  = 32
+	maxVoucherCodeLength = 32
+	maxCheckoutItems     = 200
+	maxAuditEntries      = 500
+	maxPurchases         = 500
+)
+
+var PaymentMethods = []string{
+	models.PaymentMethodCash,
+	models.PaymentMethodCard,
+	models.PaymentMethodQRIS,
+	models.PaymentMethodTransfer,
+	models.PaymentMethodKasbon,
+}
+
+// CollectedMethods are the tenders that take money in: everything but kasbon.
+// They are what a drawer is counted in and what a kasbon is paid back with.
+var CollectedMethods = []string{
+	models.PaymentMethodCash,
+	models.PaymentMethodCard,
+	models.PaymentMethodQRIS,
+	models.PaymentMethodTransfer,
+}
+
+func CreateCategory(c *models.Category) *Validator {
+	v := New()
+	v.Required("name", c.Name)
+	v.MaxLength("name", c.Name, maxNameLength)
+	v.MaxLength("description", c.Description, maxDescriptionLength)
+	return v
+}
+
+// UpdateCategory allows empty fields, which keep their current value.
+func UpdateCategory(c *models.Category) *Validator {
+	v := New()
+	v.MaxLength("name", c.Name, maxNameLength)
+	v.MaxLength("description", c.Description, maxDescriptionLength)
+	return v
+}
+
+func CreateProduct(p *models.Product) *Validator {
+	v := New()
+	v.Required("name", p.Name)
+	v.MaxLength("name", p.Name, maxNameLength)
+	v.MaxLength("sku", p.SKU, maxSKULength)
+	v.Positive("price", p.Price)
+	v.NonNegative("cost", p.Cost)
+	v.NonNegative("stock", p.Stock)
+	v.Positive("category_id", p.CategoryId)
+	return v
+}
+
+// UpdateProduct allows zero values, which keep their current value.
+func UpdateProduct(p *models.Product) *Validator {
+	v := New()
+	v.MaxLength("name", p.Name, maxNameLength)
+	v.MaxLength("sku", p.SKU, maxSKULength)
+	v.NonNegative("price", p.Price)
+	v.NonNegative("cost", p.Cost)
+	v.NonNegative("stock", p.Stock)
+	v.NonNegative("category_id", p.CategoryId)
+	return v
+}
+
+// SchedulePrice accepts only prices taking effect after now; a price change
+// effective immediately is a product update.
+func SchedulePrice(req *models.SchedulePriceRequest, now time.Time) *Validator {
+	v := New()
+	v.Positive("price", req.Price)
+	if req.EffectiveAt.IsZero() {
+		v.Check(false, "effective_at", "effective_at is required")
+	} else {
+		v.Check(req.EffectiveAt.After(now), "effective_at", "effective_at must be in the future")
+	}
+	return v
+}
+
+func PriceRule(r *models.PriceRule) *Validator {
+	v := New()
+	v.Required("name", r.Name)
+	v.MaxLength("name", r.Name, maxNameLength)
+	v.Check((r.ProductID == nil) != (r.CategoryID == nil), "product_id", "exactly one of product_id and category_id is required")
+	if r.ProductID != nil {
+		v.Positive("product_id", *r.ProductID)
+	}
+	if r.CategoryID != nil {
+		v.Positive("category_id", *r.CategoryID)
+	}
+
+	v.OneOf("type", r.Type, models.PriceRulePercentOff, models.PriceRuleAmountOff, models.PriceRuleFixedPrice)
+	if r.Type == models.PriceRuleFixedPrice {
+		v.NonNegative("value", r.Value)
+	} else {
+		v.Positive("value", r.Value)
+	}
+	if r.Type == models.PriceRulePercentOff {
+		v.Check(r.Value <= 100, "value", "value must be at most 100 for percent_off")
+	}
+
+	v.Check(len(r.Days) > 0, "days", "days must contain at least one day")
+	seen := make(map[string]bool)
+	for i, day := range r.Days {
+		field := fmt.Sprintf("days[%d]", i)
+		v.OneOf(field, day, models.Weekdays...)
+		v.Check(!seen[day], field, fmt.Sprintf("%s is listed more than once", day))
+		seen[day] = true
+	}
+
+	start, startErr := time.Parse("15:04", r.StartTime)
+	end, endErr := time.Parse("15:04", r.EndTime)
+	v.Check(startErr == nil, "start_time", "start_time must use the HH:MM format")
+	v.Check(endErr == nil, "end_time", "end_time must use the HH:MM format")
+	if startErr == nil && endErr == nil {
+		v.Check(!start.Equal(end), "end_time", "end_time must differ from start_time")
+	}
+	return v
+}
+
+func ModifierGroup(g *models.ModifierGroup) *Validator {
+	v := New()
+	v.Required("name", g.Name)
+	v.MaxLength("name", g.Name, maxNameLength)
+	v.Check((g.ProductID == nil) != (g.CategoryID == nil), "product_id", "exactly one of product_id and category_id is required")
+	if g.ProductID != nil {
+		v.Positive("product_id", *g.ProductID)
+	}
+	if g.CategoryID != nil {
+		v.Positive("category_id", *g.CategoryID)
+	}
+
+	v.NonNegative("min_select", g.MinSelect)
+	v.Positive("max_select", g.MaxSelect)
+	v.Check(g.MinSelect <= g.MaxSelect, "min_select", "min_select cannot exceed max_select")
+	v.Check(len(g.Options) > 0, "options", "options must contain at least one option")
+	v.Check(g.MinSelect <= len(g.Options), "min_select", "min_select cannot exceed the number of options")
+
+	names := make(map[string]bool, len(g.Options))
+	for i, m := range g.Options {
+		field := fmt.Sprintf("options[%d].name", i)
+		v.Required(field, m.Name)
+		v.MaxLength(field, m.Name, maxNameLength)
+		v.Check(!names[m.Name], field, fmt.Sprintf("%s is listed more than once", m.Name))
+		names[m.Name] = true
+	}
+	return v
+}
+
+// Voucher expects the code already upper-cased.
+func Voucher(voucher *models.Voucher) *Validator {
+	v := New()
+	v.Required("code", voucher.Code)
+	v.MaxLength("code", voucher.Code, maxVoucherCodeLength)
+	v.Check(strings.Trim(voucher.Code, "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-") == "", "code", "code may only contain letters, digits and -")
+	v.Required("name", voucher.Name)
+	v.MaxLength("name", voucher.Name, maxNameLength)
+
+	v.OneOf("type", voucher.Type, models.VoucherPercentOff, models.VoucherAmountOff)
+	v.Positive("value", voucher.Value)
+	if voucher.Type == models.VoucherPercentOff {
+		v.Check(voucher.Value <= 100, "value", "value must be at most 100 for percent_off")
+	} else {
+		v.Check(voucher.MaxDiscount == 0, "max_discount", "max_discount only applies to percent_off")
+	}
+	v.NonNegative("max_discount", voucher.MaxDiscount)
+	v.NonNegative("min_spend", voucher.MinSpend)
+
+	if voucher.MaxUses != nil {
+		v.Positive("max_uses", *voucher.MaxUses)
+	}
+	if voucher.PerCustomerLimit != nil {
+		v.Positive("per_customer_limit", *voucher.PerCustomerLimit)
+	}
+	return v
+}
+
+func Checkout(req *models.CheckoutRequest) *Validator {
+	v := New()
+	v.Check(len(req.Items) > 0, "items", "items must contain at least one item")
+	v.Check(len(req.Items) <= maxCheckoutItems, "items", fmt.Sprintf("items must contain at most %d items", maxCheckoutItems))
+	if req.PaymentMethod != "" {
+		v.OneOf("payment_method", req.PaymentMethod, PaymentMethods...)
+	}
+	if req.CustomerID != nil {
+		v.Positive("customer_id", *req.CustomerID)
+	}
+	v.NonNegative("redeem_points", req.RedeemPoints)
+	v.Check(req.RedeemPoints == 0 || req.CustomerID != nil, "redeem_points", "redeeming points requires a customer_id")
+	v.Check(req.PaymentMethod != models.PaymentMethodKasbon || req.CustomerID != nil, "customer_id", "kasbon requires a customer_id")
+	v.MaxLength("voucher_code", req.VoucherCode, maxVoucherCodeLength)
+
+	seen := make(map[int]int)
+	for i, item := range req.Items {
+		prefix := fmt.Sprintf("items[%d].", i)
+		v.Positive(prefix+"product_id", item.ProductID)
+		v.Positive(prefix+"quantity", item.Quantity)
+
+		for j, choice := range item.Choices {
+			choicePrefix := fmt.Sprintf("%schoices[%d].", prefix, j)
+			v.Positive(choicePrefix+"group_id", choice.GroupID)
+			v.Positive(choicePrefix+"product_id", choice.ProductID)
+			v.Positive(choicePrefix+"quantity", choice.Quantity)
+		}
+
+		v.MaxLength(prefix+"note", item.Note, maxDescriptionLength)
+		picked := make(map[int]bool, len(item.Modifiers))
+		for j, id := range item.Modifiers {
+			field := fmt.Sprintf("%smodifiers[%d]", prefix, j)
+			v.Positive(field, id)
+			v.Check(!picked[id], field, fmt.Sprintf("modifier %d is listed more than once", id))
+			picked[id] = true
+		}
+
+		// A product may be listed again with other choices, modifiers or note
+		if len(item.Choices) > 0 || len(item.Modifiers) > 0 || item.Note != "" {
+			continue
+		}
+		if first, ok := seen[item.ProductID]; ok && item.ProductID > 0 {
+			v.Check(false, prefix+"product_id", fmt.Sprintf("product %d is already listed in items[%d]", item.ProductID, first))
+		} else {
+			seen[item.ProductID] = i
+		}
+	}
+	return v
+}
+
+func SetBundle(req *models.SetBundleRequest) *Validator {
+	v := New()
+	v.Check(len(req.Components)+len(req.ChoiceGroups) > 0, "components", "a bundle needs at least one component or choice group")
+
+	seen := make(map[int]bool)
+	for i, c := range req.Components {
+		prefix := fmt.Sprintf("components[%d].", i)
+		v.Positive(prefix+"product_id", c.ProductID)
+		v.Positive(prefix+"quantity", c.Quantity)
+		v.Check(!seen[c.ProductID], prefix+"product_id", fmt.Sprintf("product %d is listed more than once", c.ProductID))
+		seen[c.ProductID] = true
+	}
+	for i, g := range req.ChoiceGroups {
+		prefix := fmt.Sprintf("choice_groups[%d].", i)
+		v.Required(prefix+"name", g.Name)
+		v.MaxLength(prefix+"name", g.Name, maxNameLength)
+		v.Positive(prefix+"category_id", g.CategoryID)
+		v.Positive(prefix+"quantity", g.Quantity)
+	}
+	return v
+}
+
+// CreateCustomer leaves member_code optional; one is generated when empty.
+func CreateCustomer(c *models.Customer) *Validator {
+	v := New()
+	v.Required("name", c.Name)
+	customerFields(v, c)
+	v.NonNegative("credit_limit", c.CreditLimit)
+	return v
+}
+
+// UpdateCustomer allows empty fields, which keep their current value.
+func UpdateCustomer(c *models.Customer) *Validator {
+	v := New()
+	customerFields(v, c)
+	return v
+}
+
+func customerFields(v *Validator, c *models.Customer) {
+	v.MaxLength("name", c.Name, maxNameLength)
+	v.MaxLength("phone", c.Phone, maxPhoneLength)
+	v.Check(strings.Trim(c.Phone, "+0123456789 -") == "", "phone", "phone may only contain digits, spaces, + and -")
+	v.MaxLength("email", c.Email, maxEmailLength)
+	if c.Email != "" {
+		at := strings.Index(c.Email, "@")
+		v.Check(at > 0 && at < len(c.Email)-1 && !strings.ContainsAny(c.Email, " \t"), "email", "email must be an email address")
+	}
+	v.MaxLength("member_code", c.MemberCode, maxMemberCodeLength)
+	v.Check(!strings.ContainsAny(c.MemberCode, " \t"), "member_code", "member_code cannot contain spaces")
+}
+
+func CreditLimit(req *models.CreditLimitRequest) *Validator {
+	v := New()
+	v.NonNegative("credit_limit", req.CreditLimit)
+	return v
+}
+
+func Repayment(req *models.RepaymentRequest) *Validator {
+	v := New()
+	v.Positive("amount", req.Amount)
+	v.OneOf("payment_method", req.PaymentMethod, CollectedMethods...)
+	v.MaxLength("note", req.Note, maxDescriptionLength)
+	return v
+}
+
+// PurchaseLimit bounds the number of transactions in a purchase history; 0 uses the default.
+func PurchaseLimit(limit int) *Validator {
+	v := New()
+	v.Check(limit >= 0 && limit <= maxPurchases, "limit", fmt.Sprintf("limit must be between 1 and %d", maxPurchases))
+	return v
+}
+
+func StatusChange(req *models.StatusChangeRequest) *Validator {
+	v := New()
+	v.MaxLength("reason", req.Reason, maxReasonLength)
+	return v
+}
+
+func CreateCart(req *models.CreateCartRequest) *Validator {
+	v := New()
+	v.MaxLength("note", req.Note, maxDescriptionLength)
+	return v
+}
+
+// CartItem validates a cart line; allowZero is set for updates, where zero removes the line.
+func CartItem(req *models.CartItemRequest, allowZero bool) *Validator {
+	v := New()
+	v.Positive("product_id", req.ProductID)
+	if allowZero {
+		v.NonNegative("quantity", req.Quantity)
+	} else {
+		v.Positive("quantity", req.Quantity)
+	}
+	return v
+}
+
+func OpenShift(req *models.OpenShiftRequest, attr models.Attribution) *Validator {
+	v := New()
+	v.Check(attr.CashierID != "", "X-Cashier-ID", "X-Cashier-ID header is required to open a shift")
+	v.Check(attr.TerminalID != "", "X-Terminal-ID", "X-Terminal-ID header is required to open a shift")
+	v.NonNegative("opening_float", req.OpeningFloat)
+	return v
+}
+
+func CashMovement(m *models.CashMovement) *Validator {
+	v := New()
+	v.OneOf("type", m.Type, models.CashMovementPayIn, models.CashMovementPayOut)
+	v.Positive("amount", m.Amount)
+	v.MaxLength("reason", m.Reason, maxReasonLength)
+	return v
+}
+
+func CloseShift(req *models.CloseShiftRequest) *Validator {
+	v := New()
+	_, ok := req.Counted[models.PaymentMethodCash]
+	v.Check(ok, "counted.cash", "counted cash is required to close a shift")
+	methods := make([]string, 0, len(req.Counted))
+	for method := range req.Counted {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	for _, method := range methods {
+		v.OneOf("counted."+method, method, CollectedMethods...)
+		v.NonNegative("counted."+method, req.Counted[method])
+	}
+	return v
+}
+
+func AuditFilter(f *models.AuditFilter) *Validator {
+	v := New()
+	if f.Entity != "" {
+		v.OneOf("entity", f.Entity, models.AuditEntityProduct, models.AuditEntityCategory, models.AuditEntityTransaction,
+			models.AuditEntityPriceRule, models.AuditEntityModifierGroup, models.AuditEntityCustomer,
+			models.AuditEntityVoucher)
+	}
+	v.NonNegative("entity_id", f.EntityID)
+	v.Check(f.Limit >= 0 && f.Limit <= maxAuditEntries, "limit", fmt.Sprintf("limit must be between 1 and %d", maxAuditEntries))
+	return v
+}
@@ -0,0 +1,63 @@
+// Package validation collects field violations for a request so they can all
+// be reported at once, before any database work is done.
+package validation
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"kasir-api/apperror"
+)
+
+type Validator struct {
+	errors []apperror.FieldError
+}
+
+func New() *Validator {
+	return &Validator{}
+}
+
+// Check records message for field when ok is false.
+func (v *Validator) Check(ok bool, field, message string) {
+	if !ok {
+		v.errors = append(v.errors, apperror.FieldError{Field: field, Message: message})
+	}
+}
+
+func (v *Validator) Required(field, value string) {
+	v.Check(strings.TrimSpace(value) != "", field, field+" is required")
+}
+
+func (v *Validator) MaxLength(field, value string, max int) {
+	v.Check(utf8.RuneCountInString(value) <= max, field, fmt.Sprintf("%s must be at most %d characters", field, max))
+}
+
+func (v *Validator) Positive(field string, value int) {
+	v.Check(value > 0, field, field+" must be greater than zero")
+}
+
+func (v *Validator) NonNegative(field string, value int) {
+	v.Check(value >= 0, field, field+" cannot be negative")
+}
+
+func (v *Validator) OneOf(field, value string, allowed ...string) {
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+	v.Check(false, field, fmt.Sprintf("%s must be one of %s", field, strings.Join(allowed, ", ")))
+}
+
+func (v *Validator) Valid() bool {
+	return len(v.errors) == 0
+}
+
+// Err returns every recorded violation as a single validation error, or nil.
+func (v *Validator) Err() error {
+	if v.Valid() {
+		return nil
+	}
+	return apperror.Invalid(v.errors...)
+}